@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/dmytro-vovk/tro/internal/boot"
 	"github.com/sirupsen/logrus"
 )
@@ -11,6 +13,22 @@ func main() {
 		logrus.Fatal(err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := c.Migrate(os.Args[2:]); err != nil {
+			logrus.Fatal(err)
+		}
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "grant-admin" {
+		if err := c.GrantAdmin(os.Args[2:]); err != nil {
+			logrus.Fatal(err)
+		}
+
+		return
+	}
+
 	go func() {
 		s, err := c.APIServer()
 		if err != nil {
@@ -22,6 +40,19 @@ func main() {
 		}
 	}()
 
+	if c.AdminEnabled() {
+		go func() {
+			s, err := c.AdminServer()
+			if err != nil {
+				logrus.Fatal(err)
+			}
+
+			if err := s.Serve("Admin server"); err != nil {
+				logrus.Fatal(err)
+			}
+		}()
+	}
+
 	s, err := c.Webserver()
 	if err != nil {
 		logrus.Fatal(err)
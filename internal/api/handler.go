@@ -1,7 +1,14 @@
 package api
 
 import (
+	"github.com/dmytro-vovk/tro/internal/api/handler/apikeys"
+	"github.com/dmytro-vovk/tro/internal/api/handler/audit"
 	"github.com/dmytro-vovk/tro/internal/api/handler/auth"
+	"github.com/dmytro-vovk/tro/internal/api/handler/middleware"
+	"github.com/dmytro-vovk/tro/internal/api/handler/roles"
+	"github.com/dmytro-vovk/tro/internal/api/handler/users"
+	"github.com/dmytro-vovk/tro/internal/api/lockout"
+	"github.com/dmytro-vovk/tro/internal/api/model"
 	"github.com/dmytro-vovk/tro/internal/api/service"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -10,18 +17,80 @@ import (
 type Authorization interface {
 	SignUp(c *gin.Context)
 	SignIn(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
 	UserIdentity(c *gin.Context)
+	Require(permissions ...model.Permission) gin.HandlerFunc
+	RequireSession(c *gin.Context)
+	NoImpersonation(c *gin.Context)
+	Impersonate(c *gin.Context)
+	JWKS(c *gin.Context)
+	SignInTOTP(c *gin.Context)
+	EnrolTOTP(c *gin.Context)
+	ActivateTOTP(c *gin.Context)
+	DisableTOTP(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	Sessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+}
+
+type Users interface {
+	List(c *gin.Context)
+	Get(c *gin.Context)
+	Create(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type Roles interface {
+	List(c *gin.Context)
+	GetUserRoles(c *gin.Context)
+	SetUserRoles(c *gin.Context)
+}
+
+type APIKeys interface {
+	List(c *gin.Context)
+	Create(c *gin.Context)
+	Revoke(c *gin.Context)
+}
+
+type Audit interface {
+	List(c *gin.Context)
 }
 
 // Handler is for REST Handler server
 type Handler struct {
-	auth Authorization
-	log  *logrus.Logger
+	auth  Authorization
+	users Users
+	roles Roles
+	keys  APIKeys
+	audit Audit
+	log   *logrus.Logger
+	// logging tells what of request and response bodies is logged
+	logging middleware.LoggingConfig
+	// trustedProxies are IPs and CIDRs of proxies whose X-Forwarded-For is believed, none by default
+	trustedProxies []string
 }
 
-func NewHandler(log *logrus.Logger, serv service.Service) *Handler {
+func NewHandler(
+	log *logrus.Logger,
+	logging middleware.LoggingConfig,
+	trustedProxies []string,
+	serv service.Service,
+	limiter *lockout.Limiter,
+	auditor service.Auditor,
+) *Handler {
 	return &Handler{
-		auth: auth.NewHandler(log, serv),
-		log:  log,
+		auth:           auth.NewHandler(log, serv, limiter, auditor),
+		users:          users.NewHandler(log, serv, auditor),
+		roles:          roles.NewHandler(log, serv, auditor),
+		keys:           apikeys.NewHandler(log, serv, auditor),
+		audit:          audit.NewHandler(log, auditor),
+		log:            log,
+		logging:        logging,
+		trustedProxies: trustedProxies,
 	}
 }
@@ -0,0 +1,117 @@
+package apikeys
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dmytro-vovk/tro/internal/api/handler/auth"
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/service"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errInvalidRequestBody = errors.New(errors.Invalid, "invalid request body")
+	errInvalidKeyID       = errors.New(errors.Invalid, "invalid API key id")
+)
+
+type Handler struct {
+	keys  service.APIKeys
+	audit service.Auditor
+	log   *logrus.Logger
+}
+
+func NewHandler(log *logrus.Logger, serv service.APIKeys, auditor service.Auditor) *Handler {
+	return &Handler{
+		keys:  serv,
+		audit: auditor,
+		log:   log,
+	}
+}
+
+type createRequest struct {
+	Name   string             `json:"name"   binding:"required,max=255"`
+	Scopes []model.Permission `json:"scopes" binding:"required"`
+}
+
+// createdKey is the only response having the key itself
+type createdKey struct {
+	model.APIKey
+	Key string `json:"key"`
+}
+
+// List returns keys of the current user, the keys themselves aren't stored
+func (h *Handler) List(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		return
+	}
+
+	keys, err := h.keys.GetAPIKeys(userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"api_keys": keys,
+	})
+}
+
+// Create responds with the key, it's the only time the key is shown
+func (h *Handler) Create(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		return
+	}
+
+	var input createRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody).SetMeta(err.Error())
+		return
+	}
+
+	apiKey, key, err := h.keys.CreateAPIKey(userID, input.Name, input.Scopes)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	event := auth.AuditEvent(c, model.AuditAPIKeyCreate, audit.Target("api_keys", apiKey.ID))
+	event.Before, event.After = audit.Diff(nil, apiKey)
+	h.audit.Audit(event)
+
+	c.JSON(http.StatusCreated, createdKey{
+		APIKey: apiKey,
+		Key:    key,
+	})
+}
+
+func (h *Handler) Revoke(c *gin.Context) {
+	userID, err := auth.GetUserID(c)
+	if err != nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.AbortWithError(http.StatusBadRequest, errInvalidKeyID)
+		return
+	}
+
+	if err := h.keys.RevokeAPIKey(userID, id); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	h.audit.Audit(auth.AuditEvent(c, model.AuditAPIKeyRevoke, audit.Target("api_keys", id)))
+
+	c.Status(http.StatusNoContent)
+}
+
+func abortWithError(c *gin.Context, err error) {
+	c.AbortWithError(errors.Status(err), err)
+}
@@ -0,0 +1,45 @@
+package apikeys
+
+import (
+	"net/http"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/openapi"
+)
+
+type idParams struct {
+	ID int `uri:"id"`
+}
+
+// Operations describes routes of the handler
+func Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/me/api-keys",
+			Tag:      "account",
+			Summary:  "List API keys, the keys themselves aren't shown",
+			Auth:     true,
+			Response: openapi.Object{"api_keys": []model.APIKey{}},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/me/api-keys",
+			Tag:      "account",
+			Summary:  "Create an API key, it's the only time the key is shown",
+			Auth:     true,
+			Request:  createRequest{},
+			Status:   http.StatusCreated,
+			Response: createdKey{},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/api/me/api-keys/:id",
+			Tag:     "account",
+			Summary: "Revoke an API key",
+			Auth:    true,
+			Params:  idParams{},
+			Status:  http.StatusNoContent,
+		},
+	}
+}
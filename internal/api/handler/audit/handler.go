@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/service"
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultLimit = 50
+
+var errInvalidRequestQuery = errors.New(errors.Invalid, "invalid request query")
+
+type Handler struct {
+	audit service.Auditor
+	log   *logrus.Logger
+}
+
+func NewHandler(log *logrus.Logger, auditor service.Auditor) *Handler {
+	return &Handler{
+		audit: auditor,
+		log:   log,
+	}
+}
+
+type listQuery struct {
+	ActorID int       `form:"actor_id" binding:"omitempty,min=1"`
+	Action  string    `form:"action"`
+	Target  string    `form:"target"`
+	From    time.Time `form:"from"     time_format:"2006-01-02T15:04:05Z07:00"`
+	To      time.Time `form:"to"       time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit   int       `form:"limit"    binding:"omitempty,min=1,max=500"`
+	Offset  int       `form:"offset"   binding:"omitempty,min=0"`
+}
+
+// List returns recent events first, action ending with "." selects all actions of the group, e.g. "auth."
+func (h *Handler) List(c *gin.Context) {
+	var query listQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestQuery).SetMeta(err.Error())
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultLimit
+	}
+
+	events, total, err := h.audit.GetAuditEvents(model.AuditFilter{
+		ActorID: query.ActorID,
+		Action:  query.Action,
+		Target:  query.Target,
+		From:    query.From,
+		To:      query.To,
+		Limit:   query.Limit,
+		Offset:  query.Offset,
+	})
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"events": events,
+		"total":  total,
+		"limit":  query.Limit,
+		"offset": query.Offset,
+	})
+}
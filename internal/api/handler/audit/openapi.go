@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/openapi"
+)
+
+// Operations describes routes of the handler
+func Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/audit",
+			Tag:         "audit",
+			Summary:     `Query the audit log, recent events first, action ending with "." selects the group, e.g. "auth."`,
+			Auth:        true,
+			Permissions: []model.Permission{model.PermAuditRead},
+			Params:      listQuery{},
+			Response: openapi.Object{
+				"events": []model.AuditEvent{},
+				"total":  0,
+				"limit":  0,
+				"offset": 0,
+			},
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditEvent makes event of the request, the actor is set if the request is authenticated
+func AuditEvent(c *gin.Context, action, target string) model.AuditEvent {
+	event := model.AuditEvent{
+		Action:    action,
+		Target:    target,
+		IP:        c.ClientIP(),
+		RequestID: requestid.From(c.Request.Context()),
+	}
+
+	if identity, ok := GetIdentity(c); ok {
+		event.ActorID, event.ImpersonatorID = identity.UserID, identity.ImpersonatorID
+	}
+
+	return event
+}
+
+// auditSignIn records sign-in of the user, the request isn't authenticated yet, so the user is set as the actor
+func (h *Handler) auditSignIn(c *gin.Context, userID int) {
+	event := AuditEvent(c, model.AuditSignIn, audit.Target("users", userID))
+	event.ActorID = userID
+	h.audit.Audit(event)
+}
@@ -2,69 +2,200 @@ package auth
 
 import (
 	"github.com/sirupsen/logrus"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/dmytro-vovk/tro/internal/api/lockout"
 	"github.com/dmytro-vovk/tro/internal/api/model"
 	"github.com/dmytro-vovk/tro/internal/api/service"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/errors"
 	"github.com/gin-gonic/gin"
 )
 
+type authService interface {
+	service.Authorization
+	service.TwoFactor
+	service.Recovery
+	service.Sessions
+	service.Impersonation
+}
+
 // todo: make private
 type Handler struct {
-	auth service.Authorization
-	log  *logrus.Logger
+	auth    authService
+	limiter *lockout.Limiter
+	audit   service.Auditor
+	log     *logrus.Logger
 }
 
-func NewHandler(log *logrus.Logger, serv service.Authorization) *Handler {
+func NewHandler(log *logrus.Logger, serv authService, limiter *lockout.Limiter, auditor service.Auditor) *Handler {
 	return &Handler{
-		auth: serv,
-		log:  log,
+		auth:    serv,
+		limiter: limiter,
+		audit:   auditor,
+		log:     log,
 	}
 }
 
-const errInvalidRequestBody = authErr("invalid request body")
+var (
+	errInvalidRequestBody = errors.New(errors.Invalid, "invalid request body")
+	errTooManyAttempts    = errors.New(errors.TooManyRequests, "too many failed attempts, try again later")
+)
+
+type signUpRequest struct {
+	Name     string `json:"name"     binding:"required"`
+	Username string `json:"username" binding:"required"` // for QR
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email"    binding:"omitempty,email"` // optional, needed for password reset
+}
+
+type signInRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Device   string `json:"device"` // name of the device shown in sessions, guessed if empty
+}
 
-type authErr string
+// mfaChallenge is the response to sign-in when a second factor is required
+type mfaChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
 
-func (e authErr) Error() string {
-	return string(e)
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 func (h *Handler) SignUp(c *gin.Context) {
-	var input model.User
+	var input signUpRequest
 	if err := c.BindJSON(&input); err != nil {
 		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody)
 		return
 	}
 
-	id, err := h.auth.CreateUser(input)
+	id, err := h.auth.CreateUser(c.Request.Context(), model.User{
+		Name:     input.Name,
+		Username: input.Username,
+		Password: input.Password,
+		Email:    input.Email,
+	})
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(errors.Status(err), err)
 		return
 	}
 
+	event := AuditEvent(c, model.AuditSignUp, audit.Target("users", id))
+	event.ActorID = id
+	_, event.After = audit.Diff(nil, map[string]interface{}{
+		"name":     input.Name,
+		"username": input.Username,
+		"email":    input.Email,
+	})
+	h.audit.Audit(event)
+
 	c.JSON(http.StatusOK, map[string]interface{}{
 		"id": id,
 	})
 }
 
 func (h *Handler) SignIn(c *gin.Context) {
-	var input struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required"`
+	var input signInRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody)
+		return
 	}
+
+	user, ip := lockout.UserKey(strings.ToLower(input.Username)), lockout.IPKey(c.ClientIP())
+	if h.tooManyAttempts(c, user, ip) {
+		return
+	}
+
+	tokens, err := h.auth.GenerateToken(c.Request.Context(), input.Username, input.Password, device(c, input.Device))
+	if errors.Is(err, model.ErrInvalidCredentials) {
+		h.limiter.Fail(user, ip)
+		h.audit.Audit(AuditEvent(c, model.AuditSignInFailed, "username:"+input.Username))
+	}
+
+	if err != nil {
+		c.AbortWithError(errors.Status(err), err)
+		return
+	}
+
+	// failures are forgiven once the second factor is verified too, so the password doesn't reset guesses of codes
+	if tokens.MFAToken != "" {
+		c.JSON(http.StatusOK, mfaChallenge{
+			MFARequired: true,
+			MFAToken:    tokens.MFAToken,
+		})
+
+		return
+	}
+
+	// the IP isn't forgiven, an attacker knowing one password could guess others then
+	h.limiter.Succeed(user)
+	h.auditSignIn(c, tokens.UserID)
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) Refresh(c *gin.Context) {
+	var input refreshRequest
 	if err := c.BindJSON(&input); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody)
 		return
 	}
 
-	token, err := h.auth.GenerateToken(input.Username, input.Password)
+	tokens, err := h.auth.RefreshToken(c.Request.Context(), input.RefreshToken, device(c, ""))
 	if err != nil {
+		c.AbortWithError(errors.Status(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the session of the access token
+func (h *Handler) Logout(c *gin.Context) {
+	if err := h.auth.Logout(c.GetString(sessionContext)); err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"token": token,
-	})
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll revokes all sessions of the user
+func (h *Handler) LogoutAll(c *gin.Context) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.auth.LogoutAll(userID); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// JWKS publishes public keys tokens can be verified with
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.auth.JWKS())
+}
+
+// tooManyAttempts refuses the request if any of the keys has to wait
+func (h *Handler) tooManyAttempts(c *gin.Context, keys ...string) bool {
+	wait := h.limiter.Check(keys...)
+	if wait == 0 {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.AbortWithError(http.StatusTooManyRequests, errTooManyAttempts)
+
+	return true
 }
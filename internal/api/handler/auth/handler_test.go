@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/api/lockout"
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *memAuth) CreateUser(_ context.Context, user model.User) (int, error) {
+	m.user = user
+	m.user.ID = 1
+
+	return m.user.ID, nil
+}
+
+func TestSignUp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, _ := test.NewNullLogger()
+	serv := &memAuth{}
+	h := NewHandler(log, serv, lockout.New(lockout.Config{}, nil), memAuditor{})
+
+	router := gin.New()
+	router.POST("/auth/sign-up", h.SignUp)
+
+	signUp := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/sign-up", strings.NewReader(body)))
+
+		return w
+	}
+
+	w := signUp(`{"name": "Alice", "username": "alice", "password": "secret"}`)
+	require.Equal(t, http.StatusOK, w.Code, "created_at isn't expected from clients")
+	assert.JSONEq(t, `{"id": 1}`, w.Body.String())
+	assert.Equal(t, model.User{ID: 1, Name: "Alice", Username: "alice", Password: "secret"}, serv.user)
+
+	for _, body := range []string{
+		`{"name": "Bob", "username": "bob"}`,
+		`{"name": "Bob", "username": "bob", "password": "secret", "email": "bob"}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, signUp(body).Code, body)
+	}
+}
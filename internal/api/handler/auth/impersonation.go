@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidUserIDParam = errors.New(errors.Invalid, "invalid user id")
+
+// Impersonate issues the admin a short-lived access token of the user
+func (h *Handler) Impersonate(c *gin.Context) {
+	admin, ok := GetIdentity(c)
+	if !ok {
+		c.AbortWithError(http.StatusUnauthorized, errEmptyHeader)
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || userID <= 0 {
+		c.AbortWithError(http.StatusBadRequest, errInvalidUserIDParam)
+		return
+	}
+
+	tokens, err := h.auth.Impersonate(admin, userID)
+	if err != nil {
+		c.AbortWithError(errors.Status(err), err)
+		return
+	}
+
+	h.audit.Audit(AuditEvent(c, model.AuditImpersonate, audit.Target("users", userID)))
+
+	c.JSON(http.StatusOK, tokens)
+}
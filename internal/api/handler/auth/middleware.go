@@ -4,18 +4,27 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/errors"
 	"github.com/gin-gonic/gin"
 )
 
-const userContext = "userID"
-
 const (
-	errEmptyHeader    = authErr("empty authorization header")
-	errInvalidHeader  = authErr("invalid authorization header")
-	errEmptyToken     = authErr("token is empty")
-	errInvalidToken   = authErr("failed to parse token")
-	errUserIDNotFound = authErr("user id not found")
-	errInvalidUserID  = authErr("user id is of invalid type")
+	userContext     = "userID"
+	sessionContext  = "sessionID"
+	identityContext = "identity"
+)
+
+var (
+	errEmptyHeader    = errors.New(errors.Unauthorized, "empty authorization header")
+	errInvalidHeader  = errors.New(errors.Unauthorized, "invalid authorization header")
+	errEmptyToken     = errors.New(errors.Unauthorized, "token is empty")
+	errInvalidToken   = errors.New(errors.Unauthorized, "failed to parse token")
+	errUserIDNotFound = errors.New(errors.Internal, "user id not found")
+	errInvalidUserID  = errors.New(errors.Internal, "user id is of invalid type")
+	errForbidden      = errors.New(errors.Forbidden, "permission denied")
+	errSessionOnly    = errors.New(errors.Forbidden, "not allowed with API key")
+	errImpersonating  = errors.New(errors.Forbidden, "not allowed while impersonating")
 )
 
 func (h *Handler) UserIdentity(c *gin.Context) {
@@ -41,13 +50,66 @@ func (h *Handler) UserIdentity(c *gin.Context) {
 		return
 	}
 
-	userID, err := h.auth.ParseToken(headerParts[1])
+	identity, err := h.auth.ParseToken(c.Request.Context(), headerParts[1])
 	if err != nil {
 		c.AbortWithError(http.StatusUnauthorized, errInvalidToken)
 		return
 	}
 
-	c.Set(userContext, userID)
+	c.Set(userContext, identity.UserID)
+	c.Set(sessionContext, identity.SessionID)
+	c.Set(identityContext, identity)
+}
+
+// Require lets through only identities having all the permissions, it must follow UserIdentity
+func (h *Handler) Require(permissions ...model.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := c.Value(identityContext).(model.Identity)
+		if !ok {
+			c.AbortWithError(http.StatusUnauthorized, errEmptyHeader)
+			return
+		}
+
+		if !identity.Can(permissions...) {
+			c.AbortWithError(http.StatusForbidden, errForbidden)
+			return
+		}
+	}
+}
+
+// RequireSession refuses API keys, so a leaked key can't manage credentials, it must follow UserIdentity
+func (h *Handler) RequireSession(c *gin.Context) {
+	identity, ok := c.Value(identityContext).(model.Identity)
+	if !ok {
+		c.AbortWithError(http.StatusUnauthorized, errEmptyHeader)
+		return
+	}
+
+	if identity.APIKeyID != 0 {
+		c.AbortWithError(http.StatusForbidden, errSessionOnly)
+		return
+	}
+}
+
+// NoImpersonation refuses sensitive actions to admins acting as other users, it must follow UserIdentity
+func (h *Handler) NoImpersonation(c *gin.Context) {
+	identity, ok := c.Value(identityContext).(model.Identity)
+	if !ok {
+		c.AbortWithError(http.StatusUnauthorized, errEmptyHeader)
+		return
+	}
+
+	if identity.ImpersonatorID != 0 {
+		c.AbortWithError(http.StatusForbidden, errImpersonating)
+		return
+	}
+}
+
+// GetIdentity returns identity of the request, false if it's not authenticated (yet)
+func GetIdentity(c *gin.Context) (model.Identity, bool) {
+	identity, ok := c.Value(identityContext).(model.Identity)
+
+	return identity, ok
 }
 
 func GetUserID(c *gin.Context) (int, error) {
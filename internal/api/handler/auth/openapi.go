@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/openapi"
+)
+
+type userIDParams struct {
+	ID int `uri:"id"`
+}
+
+// Operations describes routes of the handler
+func Operations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method:   http.MethodPost,
+			Path:     "/auth/sign-up",
+			Tag:      "auth",
+			Summary:  "Create an account",
+			Request:  signUpRequest{},
+			Response: openapi.Object{"id": 0},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/auth/sign-in",
+			Tag:      "auth",
+			Summary:  "Sign in, users with TOTP enabled get a challenge for /auth/sign-in/totp instead of tokens",
+			Request:  signInRequest{},
+			Response: openapi.OneOf{model.Tokens{}, mfaChallenge{}},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/auth/sign-in/totp",
+			Tag:      "auth",
+			Summary:  "Complete sign-in with a TOTP or recovery code",
+			Request:  signInTOTPRequest{},
+			Response: model.Tokens{},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/auth/refresh",
+			Tag:      "auth",
+			Summary:  "Exchange the refresh token for new tokens",
+			Request:  refreshRequest{},
+			Response: model.Tokens{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/auth/logout",
+			Tag:     "auth",
+			Summary: "End the current session",
+			Auth:    true,
+			Status:  http.StatusNoContent,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/auth/logout-all",
+			Tag:     "auth",
+			Summary: "End all sessions of the user",
+			Auth:    true,
+			Status:  http.StatusNoContent,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/auth/password/forgot",
+			Tag:     "auth",
+			Summary: "Mail a password reset link, the response is the same for unknown emails",
+			Request: forgotPasswordRequest{},
+			Status:  http.StatusAccepted,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/auth/password/reset",
+			Tag:     "auth",
+			Summary: "Set a new password with the mailed token, all sessions end",
+			Request: resetPasswordRequest{},
+			Status:  http.StatusNoContent,
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/auth/verify",
+			Tag:      "auth",
+			Summary:  "Verify the email with the mailed token",
+			Params:   verifyEmailRequest{},
+			Response: openapi.Object{"message": ""},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/auth/verify",
+			Tag:      "auth",
+			Summary:  "Verify the email with the mailed token",
+			Request:  verifyEmailRequest{},
+			Response: openapi.Object{"message": ""},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/api/users/:id/impersonate",
+			Tag:         "users",
+			Summary:     "Issue a short-lived access token of the user, no refresh token is issued",
+			Auth:        true,
+			Permissions: []model.Permission{model.PermUsersImpersonate},
+			Params:      userIDParams{},
+			Response:    model.Tokens{},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/me/totp",
+			Tag:      "account",
+			Summary:  "Start TOTP enrolment",
+			Auth:     true,
+			Response: enrolment{},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/me/totp/activate",
+			Tag:      "account",
+			Summary:  "Enable TOTP with a code from the app, recovery codes are shown once",
+			Auth:     true,
+			Request:  codeRequest{},
+			Response: openapi.Object{"recovery_codes": []string{}},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/me/totp/disable",
+			Tag:     "account",
+			Summary: "Disable TOTP",
+			Auth:    true,
+			Request: disableRequest{},
+			Status:  http.StatusNoContent,
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/me/sessions",
+			Tag:      "account",
+			Summary:  "List active sessions",
+			Auth:     true,
+			Response: openapi.Object{"sessions": []model.Session{}},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/api/me/sessions/:id",
+			Tag:     "account",
+			Summary: "End the session and close its connections",
+			Auth:    true,
+			Status:  http.StatusNoContent,
+		},
+	}
+}
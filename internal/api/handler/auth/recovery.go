@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"    binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+// ForgotPassword responds the same whether the email is known or not
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var input forgotPasswordRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody)
+		return
+	}
+
+	if err := h.auth.ForgotPassword(c.Request.Context(), input.Email); err != nil {
+		abortWithRecoveryError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var input resetPasswordRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody)
+		return
+	}
+
+	if err := h.auth.ResetPassword(input.Token, input.Password); err != nil {
+		abortWithRecoveryError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyEmail takes the token from the query of mailed links or from the body
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var input verifyEmailRequest
+	if err := c.ShouldBind(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody)
+		return
+	}
+
+	if err := h.auth.VerifyEmail(input.Token); err != nil {
+		abortWithRecoveryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "email is verified",
+	})
+}
+
+// abortWithRecoveryError responds 400 to bad tokens, there are no credentials to be unauthorized with
+func abortWithRecoveryError(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrInvalidToken) {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	c.AbortWithError(errors.Status(err), err)
+}
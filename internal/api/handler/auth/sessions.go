@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// Sessions lists active sessions of the user, the one of the request is marked current
+func (h *Handler) Sessions(c *gin.Context) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return
+	}
+
+	sessions, err := h.auth.GetSessions(userID)
+	if err != nil {
+		c.AbortWithError(errors.Status(err), err)
+		return
+	}
+
+	current := c.GetString(sessionContext)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession ends the session and closes its connections, revoking the current one is a logout
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.auth.RevokeSession(userID, c.Param("id")); err != nil {
+		c.AbortWithError(errors.Status(err), err)
+		return
+	}
+
+	h.audit.Audit(AuditEvent(c, model.AuditSessionRevoke, audit.Target("sessions", c.Param("id"))))
+
+	c.Status(http.StatusNoContent)
+}
+
+// device describes the client of the request, the name is guessed from the user agent unless given
+func device(c *gin.Context, name string) model.Device {
+	userAgent := c.Request.UserAgent()
+	if name == "" {
+		name = deviceName(userAgent)
+	}
+
+	return model.Device{
+		Name:      name,
+		UserAgent: userAgent,
+		IP:        c.ClientIP(),
+	}
+}
+
+// deviceName makes "Browser on OS" of the user agent, well known ones only
+func deviceName(userAgent string) string {
+	find := func(known [][2]string) string {
+		for _, k := range known {
+			if strings.Contains(userAgent, k[0]) {
+				return k[1]
+			}
+		}
+
+		return ""
+	}
+
+	// order matters, e.g. Edge and Chrome mention Safari too
+	browser := find([][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	})
+	os := find([][2]string{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	default:
+		return "Unknown device"
+	}
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dmytro-vovk/tro/internal/api/lockout"
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/gin-gonic/gin"
+	qr "github.com/skip2/go-qrcode"
+)
+
+const qrSize = 256
+
+type codeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// disableRequest has the code optional, enrolment which isn't activated yet is cancelled without one
+type disableRequest struct {
+	Code string `json:"code"`
+}
+
+type signInTOTPRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code"      binding:"required"`
+	Device   string `json:"device"`
+}
+
+// enrolment is the response to TOTP enrolment
+type enrolment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QR     []byte `json:"qr"` // PNG, base64 encoded
+}
+
+// EnrolTOTP starts enrolment, the key URI is returned along with its QR code PNG
+func (h *Handler) EnrolTOTP(c *gin.Context) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return
+	}
+
+	e, err := h.auth.EnrolTOTP(userID)
+	if err != nil {
+		abortWithTOTPError(c, err)
+		return
+	}
+
+	png, err := qr.Encode(e.URI, qr.Medium, qrSize)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrolment{
+		Secret: e.Secret,
+		URI:    e.URI,
+		QR:     png,
+	})
+}
+
+// ActivateTOTP enables the second factor once the user enters a code from the app
+func (h *Handler) ActivateTOTP(c *gin.Context) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return
+	}
+
+	var input codeRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody)
+		return
+	}
+
+	codes, err := h.auth.ActivateTOTP(userID, input.Code)
+	if err != nil {
+		abortWithTOTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	userID, err := GetUserID(c)
+	if err != nil {
+		return
+	}
+
+	var input disableRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody)
+		return
+	}
+
+	if err := h.auth.DisableTOTP(userID, input.Code); err != nil {
+		abortWithTOTPError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SignInTOTP is the second step of sign-in for users with TOTP enabled
+func (h *Handler) SignInTOTP(c *gin.Context) {
+	var input signInTOTPRequest
+	if err := c.BindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody)
+		return
+	}
+
+	ip := lockout.IPKey(c.ClientIP())
+	if h.tooManyAttempts(c, ip) {
+		return
+	}
+
+	u, err := h.auth.ChallengedUser(input.MFAToken)
+	if errors.Is(err, model.ErrInvalidToken) {
+		h.limiter.Fail(ip)
+		c.AbortWithError(http.StatusUnauthorized, err)
+		return
+	}
+
+	if err != nil {
+		abortWithTOTPError(c, err)
+		return
+	}
+
+	// codes are counted with passwords of the account, so guesses spread over IPs are slowed down too
+	user := lockout.UserKey(strings.ToLower(u.Username))
+	if h.tooManyAttempts(c, user) {
+		return
+	}
+
+	tokens, err := h.auth.SignInTOTP(input.MFAToken, input.Code, device(c, input.Device))
+	if errors.Is(err, model.ErrInvalidToken) || errors.Is(err, model.ErrInvalidCode) {
+		h.limiter.Fail(user, ip)
+		c.AbortWithError(http.StatusUnauthorized, err)
+		return
+	}
+
+	if err != nil {
+		abortWithTOTPError(c, err)
+		return
+	}
+
+	h.limiter.Succeed(user)
+	h.auditSignIn(c, tokens.UserID)
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// abortWithTOTPError responds 422 to wrong codes, the request itself is well-formed
+func abortWithTOTPError(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrInvalidCode) {
+		c.AbortWithError(http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	c.AbortWithError(errors.Status(err), err)
+}
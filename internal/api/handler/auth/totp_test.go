@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/lockout"
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/service"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testMFAToken = "mfa-token"
+	testCode     = "123456"
+)
+
+type memAuth struct {
+	authService
+	user model.User
+}
+
+func (m *memAuth) ChallengedUser(mfaToken string) (model.User, error) {
+	if mfaToken != testMFAToken {
+		return model.User{}, model.ErrInvalidToken
+	}
+
+	return m.user, nil
+}
+
+func (m *memAuth) SignInTOTP(mfaToken, code string, _ model.Device) (model.Tokens, error) {
+	if code != testCode {
+		return model.Tokens{}, model.ErrInvalidCode
+	}
+
+	return model.Tokens{AccessToken: "access", UserID: m.user.ID}, nil
+}
+
+type memAuditor struct {
+	service.Auditor
+}
+
+func (memAuditor) Audit(model.AuditEvent) {}
+
+func TestSignInTOTPLocksAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, _ := test.NewNullLogger()
+	limiter := lockout.New(lockout.Config{Attempts: 3, BaseDelay: time.Minute}, nil)
+	h := NewHandler(log, &memAuth{user: model.User{ID: 1, Username: "Alice"}}, limiter, memAuditor{})
+
+	router := gin.New()
+	router.POST("/auth/sign-in/totp", h.SignInTOTP)
+
+	signIn := func(ip int, code string) int {
+		body := fmt.Sprintf(`{"mfa_token": %q, "code": %q}`, testMFAToken, code)
+		r := httptest.NewRequest(http.MethodPost, "/auth/sign-in/totp", strings.NewReader(body))
+		r.RemoteAddr = fmt.Sprintf("192.0.2.%d:40000", ip)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	ip := 1
+	for ; ip <= 4; ip++ {
+		assert.Equal(t, http.StatusUnauthorized, signIn(ip, "000000"), "every guess comes from another IP")
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, signIn(ip, testCode), "the account is locked, whatever the IP")
+	assert.NotZero(t, limiter.Check(lockout.UserKey("alice")), "codes count with passwords of the username")
+}
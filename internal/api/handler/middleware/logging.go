@@ -3,35 +3,42 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	runtime "github.com/banzaicloud/logrus-runtime-formatter"
+	"github.com/dmytro-vovk/tro/internal/api/handler/auth"
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/dmytro-vovk/tro/internal/requestid"
 
 	"github.com/gin-gonic/gin"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
 )
 
-type errString string
+var errInvalidRequestBody = errors.New(errors.Invalid, "invalid request body")
 
-func (e errString) Error() string {
-	return string(e)
-}
-
-const errInvalidRequestBody errString = "invalid request body"
-
-func Logger(log *logrus.Logger) gin.HandlerFunc {
+// Logger logs requests with their responses, sensitive fields of bodies are redacted as configured
+func Logger(log *logrus.Logger, config LoggingConfig) gin.HandlerFunc {
 	setupFormatter(log.Formatter)
+	b := newBodies(config)
 
 	return func(c *gin.Context) {
-		req, err := newRequest(c)
-		entry := log.WithFields(logrus.Fields{
+		req, body, err := newRequest(c)
+		entry := requestid.Entry(c.Request.Context(), log).WithFields(logrus.Fields{
 			"client_ip": c.ClientIP(),
-			"user_id":   "1",
 			"request":   req,
 		})
 
-		defer newResponseWriter(c).handle(c, entry)
+		w := newResponseWriter(c)
+		// identity and route options are known once the chain is done
+		defer func() {
+			if !c.GetBool(noBodyContext) {
+				req.Body = b.format(body, c.ContentType())
+				w.bodies = b
+			}
+
+			w.handle(c, withIdentity(c, entry))
+		}()
 
 		if err != nil {
 			entry.Warningln("Can't log request:", err)
@@ -43,6 +50,21 @@ func Logger(log *logrus.Logger) gin.HandlerFunc {
 	}
 }
 
+// withIdentity adds who made the request, impersonated requests are marked with the admin's id
+func withIdentity(c *gin.Context, entry *logrus.Entry) *logrus.Entry {
+	identity, ok := auth.GetIdentity(c)
+	if !ok {
+		return entry
+	}
+
+	entry = entry.WithField("user_id", identity.UserID)
+	if identity.ImpersonatorID != 0 {
+		entry = entry.WithField("impersonator_id", identity.ImpersonatorID)
+	}
+
+	return entry
+}
+
 func setupFormatter(f logrus.Formatter) {
 	switch t := f.(type) {
 	case *logrus.TextFormatter:
@@ -54,7 +76,7 @@ func setupFormatter(f logrus.Formatter) {
 }
 
 type request struct {
-	ID        string      `json:"id"` // todo: implement
+	ID        string      `json:"id"`
 	UserAgent string      `json:"user_agent"`
 	Scheme    string      `json:"scheme"`
 	Host      string      `json:"host"`
@@ -63,8 +85,10 @@ type request struct {
 	Body      interface{} `json:"body,omitempty"`
 }
 
-func newRequest(c *gin.Context) (*request, error) {
+// newRequest returns the request to be logged and its body, the body is logged once the route is known
+func newRequest(c *gin.Context) (*request, []byte, error) {
 	r := &request{
+		ID:        requestid.From(c.Request.Context()),
 		UserAgent: c.Request.UserAgent(),
 		Scheme:    getRequestScheme(c),
 		Host:      c.Request.Host,
@@ -73,19 +97,16 @@ func newRequest(c *gin.Context) (*request, error) {
 	}
 
 	body, err := getRequestBody(c)
-	if err != nil {
-		return r, err
-	}
 
-	r.Body = prettify(body)
-	return r, nil
+	return r, body, err
 }
 
 func (r *request) String() string { return jsonify(r) }
 
 type responseWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body   *bytes.Buffer
+	bodies *bodies // nil if the body isn't logged
 }
 
 func newResponseWriter(c *gin.Context) *responseWriter {
@@ -116,20 +137,28 @@ func (w *responseWriter) handle(c *gin.Context, entry *logrus.Entry) {
 			entry.Infof("%d of %d error in chain: %s", i+1, len(c.Errors), ginErr)
 		}
 
-		c.JSON(code, gin.H{
-			"error": apiErr{
+		// the chain is logged above, clients only get the public message of the domain error
+		c.JSON(code, ErrorResponse{
+			Error: apiErr{
 				Code:    code,
 				Status:  http.StatusText(code),
-				Message: err.Error(),
+				Message: errors.Public(err.Err),
 				Details: err.Meta,
 			},
 		})
 	}
 
-	entry.WithField("response", &response{
-		Code: code,
-		Body: prettify(w.body.Bytes()),
-	}).Info("Request processed")
+	resp := &response{Code: code}
+	if w.bodies != nil {
+		resp.Body = w.bodies.format(w.body.Bytes(), w.Header().Get("Content-Type"))
+	}
+
+	entry.WithField("response", resp).Info("Request processed")
+}
+
+// ErrorResponse is the body of all failed requests
+type ErrorResponse struct {
+	Error apiErr `json:"error"`
 }
 
 type apiErr struct {
@@ -161,21 +190,13 @@ func getRequestScheme(c *gin.Context) string {
 func getRequestBody(c *gin.Context) ([]byte, error) {
 	payload, err := c.GetRawData()
 	if err != nil {
-		return nil, errors.Wrap(err, "can't read request body")
+		return nil, fmt.Errorf("can't read request body: %w", err)
 	}
 
 	c.Request.Body = io.NopCloser(bytes.NewBuffer(payload))
 	return payload, nil
 }
 
-func prettify(payload []byte) interface{} {
-	if json.Valid(payload) {
-		return json.RawMessage(payload)
-	}
-
-	return string(payload)
-}
-
 func jsonify(v interface{}) string {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerRedaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, hook := test.NewNullLogger()
+	router := gin.New()
+	router.Use(Logger(log, LoggingConfig{Redact: []string{"$.users.email"}, MaxBodySize: 200}))
+
+	echo := func(c *gin.Context) {
+		var v interface{}
+		_ = c.ShouldBindJSON(&v)
+		c.JSON(http.StatusOK, v)
+	}
+	router.POST("/echo", echo)
+	router.POST("/quiet", NoBodyLogging, echo)
+	router.POST("/form", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	logged := func(method, path, contentType, body string) (interface{}, interface{}) {
+		t.Helper()
+
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+
+		entry := hook.LastEntry()
+		require.NotNil(t, entry)
+
+		return entry.Data["request"].(*request).Body, entry.Data["response"].(*response).Body
+	}
+
+	req, resp := logged(http.MethodPost, "/echo", gin.MIMEJSON,
+		`{"username": "bob", "Password": "secret1", "refresh_token": "t", "users": [{"email": "a@b.c", "name": "A"}]}`)
+	expected := `{"username": "bob", "Password": "[REDACTED]", "refresh_token": "[REDACTED]",
+		"users": [{"email": "[REDACTED]", "name": "A"}]}`
+	assert.JSONEq(t, expected, string(req.(json.RawMessage)))
+	assert.JSONEq(t, expected, string(resp.(json.RawMessage)), "responses are redacted too")
+
+	req, _ = logged(http.MethodPost, "/echo", gin.MIMEJSON, `{"email": "a@b.c"}`)
+	assert.JSONEq(t, `{"email": "a@b.c"}`, string(req.(json.RawMessage)), "paths are matched from the root")
+
+	req, resp = logged(http.MethodPost, "/quiet", gin.MIMEJSON, `{"name": "A"}`)
+	assert.Nil(t, req)
+	assert.Nil(t, resp)
+
+	req, _ = logged(http.MethodPost, "/form", gin.MIMEPOSTForm, "username=bob&password=secret1")
+	assert.Equal(t, "password=%5BREDACTED%5D&username=bob", req)
+
+	req, _ = logged(http.MethodPost, "/echo", gin.MIMEJSON, `{"username": "bob", "password": "secret1",}`)
+	assert.Equal(t, "[unparseable body, 43 bytes]", req, "malformed JSON isn't logged")
+
+	req, _ = logged(http.MethodPost, "/echo", gin.MIMEPlain, `{"password": "secret1",}`)
+	assert.Equal(t, "[unparseable body, 24 bytes]", req, "whatever the content type is")
+
+	_, resp = logged(http.MethodPost, "/echo", gin.MIMEJSON, `{"secret": "JBSWY3DP", "uri": "otpauth://totp/tro:bob?secret=JBSWY3DP"}`)
+	assert.JSONEq(t, `{"secret": "[REDACTED]", "uri": "[REDACTED]"}`, string(resp.(json.RawMessage)))
+
+	req, _ = logged(http.MethodPost, "/echo", gin.MIMEJSON, `{"name": "`+strings.Repeat("a", 300)+`"}`)
+	assert.Equal(t, `{"name":"`+strings.Repeat("a", 191)+`... (311 bytes)`, req)
+}
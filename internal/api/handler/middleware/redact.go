@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	redacted           = "[REDACTED]"
+	defaultMaxBodySize = 4 << 10
+	noBodyContext      = "noBodyLogging"
+)
+
+// DefaultRedact are field names whose values are never logged, e.g. of sign-in, tokens and API keys,
+// uri is otpauth:// URI of TOTP enrolment, it has the secret in its query
+var DefaultRedact = []string{
+	"password", "*_password", "*token", "secret", "key", "code", "recovery_codes", "qr", "uri", "authorization",
+}
+
+// LoggingConfig tells what of request and response bodies is logged
+type LoggingConfig struct {
+	// Redact lists rules in addition to DefaultRedact, a rule is either a field name at any depth,
+	// case-insensitive with * wildcards (e.g. "*_secret"), or a path from the root starting with "$."
+	// (e.g. "$.users.email", arrays are passed through, * matches any field)
+	Redact      []string `mapstructure:"redact"`
+	MaxBodySize int      `mapstructure:"max_body_size"` // longer bodies are cut, 4 KiB by default, negative for no limit
+}
+
+// NoBodyLogging is a route middleware, bodies of the route's requests and responses aren't logged
+func NoBodyLogging(c *gin.Context) {
+	c.Set(noBodyContext, true)
+}
+
+// bodies formats bodies for logs
+type bodies struct {
+	names   []string   // field name patterns, lowercase
+	paths   [][]string // segments of paths from the root
+	maxSize int
+}
+
+func newBodies(c LoggingConfig) *bodies {
+	b := &bodies{maxSize: c.MaxBodySize}
+	if b.maxSize == 0 {
+		b.maxSize = defaultMaxBodySize
+	}
+
+	for _, rule := range append(append([]string{}, DefaultRedact...), c.Redact...) {
+		if strings.HasPrefix(rule, "$.") {
+			b.paths = append(b.paths, strings.Split(rule[2:], "."))
+		} else {
+			b.names = append(b.names, strings.ToLower(rule))
+		}
+	}
+
+	return b
+}
+
+// format returns the body to be logged, values of sensitive JSON and form fields are redacted
+func (b *bodies) format(payload []byte, contentType string) interface{} {
+	if len(payload) == 0 {
+		return nil
+	}
+
+	var v interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err == nil {
+		if redactedJSON, err := json.Marshal(b.redact(v, nil)); err == nil {
+			return b.cut(redactedJSON, true)
+		}
+	}
+
+	// handlers bind JSON whatever the content type is, so malformed JSON may still hold a password
+	if strings.Contains(contentType, "json") || looksLikeJSON(payload) {
+		return fmt.Sprintf("[unparseable body, %d bytes]", len(payload))
+	}
+
+	if strings.HasPrefix(contentType, gin.MIMEPOSTForm) {
+		if values, err := url.ParseQuery(string(payload)); err == nil {
+			for field := range values {
+				if b.sensitive(field, []string{field}) {
+					values[field] = []string{redacted}
+				}
+			}
+
+			return b.cut([]byte(values.Encode()), false)
+		}
+	}
+
+	return b.cut(payload, false)
+}
+
+func looksLikeJSON(payload []byte) bool {
+	payload = bytes.TrimSpace(payload)
+
+	return len(payload) > 0 && (payload[0] == '{' || payload[0] == '[')
+}
+
+func (b *bodies) redact(v interface{}, at []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for field, value := range v {
+			fieldPath := append(at[:len(at):len(at)], field)
+			if b.sensitive(field, fieldPath) {
+				v[field] = redacted
+			} else {
+				v[field] = b.redact(value, fieldPath)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = b.redact(v[i], at)
+		}
+	}
+
+	return v
+}
+
+func (b *bodies) sensitive(field string, at []string) bool {
+	field = strings.ToLower(field)
+	for _, pattern := range b.names {
+		if ok, _ := path.Match(pattern, field); ok {
+			return true
+		}
+	}
+
+	for _, p := range b.paths {
+		if matchPath(p, at) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchPath(pattern, at []string) bool {
+	if len(pattern) != len(at) {
+		return false
+	}
+
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != at[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cut limits size of the body, cut JSON is logged as a string
+func (b *bodies) cut(payload []byte, isJSON bool) interface{} {
+	if b.maxSize > 0 && len(payload) > b.maxSize {
+		return fmt.Sprintf("%s... (%d bytes)", bytes.ToValidUTF8(payload[:b.maxSize], nil), len(payload))
+	}
+
+	if isJSON {
+		return json.RawMessage(payload)
+	}
+
+	return string(payload)
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID takes id of the request from X-Request-ID header or makes one, it's echoed in the response.
+// It goes before Logger, so the request is logged with its id.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := requestid.Of(c.Request)
+		c.Request = c.Request.WithContext(requestid.With(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+	}
+}
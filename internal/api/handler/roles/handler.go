@@ -0,0 +1,130 @@
+package roles
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dmytro-vovk/tro/internal/api/handler/auth"
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/service"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errInvalidRequestBody = errors.New(errors.Invalid, "invalid request body")
+	errInvalidUserID      = errors.New(errors.Invalid, "invalid user id")
+)
+
+type Handler struct {
+	roles service.Roles
+	audit service.Auditor
+	log   *logrus.Logger
+}
+
+func NewHandler(log *logrus.Logger, serv service.Roles, auditor service.Auditor) *Handler {
+	return &Handler{
+		roles: serv,
+		audit: auditor,
+		log:   log,
+	}
+}
+
+func (h *Handler) List(c *gin.Context) {
+	roles, err := h.roles.GetRoles()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"roles": roles,
+	})
+}
+
+func (h *Handler) GetUserRoles(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.roles.GetUserRoles(id)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"roles": roles,
+	})
+}
+
+type setRolesRequest struct {
+	Roles []string `json:"roles" binding:"required"` // names of the roles
+}
+
+// SetUserRoles replaces roles of the user, they apply when the user's access token is refreshed
+func (h *Handler) SetUserRoles(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	var input setRolesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody).SetMeta(err.Error())
+		return
+	}
+
+	before, err := h.roles.GetUserRoles(id)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.roles.SetUserRoles(id, input.Roles); err != nil {
+		// unknown roles are a fault of the input, unlike an unknown user of the path
+		if errors.Is(err, model.ErrRoleNotFound) {
+			err = errors.Wrap(err, errors.Invalid, "unknown role")
+		}
+
+		c.AbortWithError(errors.Status(err), err)
+		return
+	}
+
+	after, err := h.roles.GetUserRoles(id)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	event := auth.AuditEvent(c, model.AuditUserRoles, audit.Target("users", id))
+	event.Before, event.After = audit.Diff(roleNames(before), roleNames(after))
+	h.audit.Audit(event)
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"roles": after,
+	})
+}
+
+// roleNames is what audit records of roles
+func roleNames(roles []model.Role) map[string][]string {
+	names := []string{}
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+
+	return map[string][]string{"roles": names}
+}
+
+func userID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.AbortWithError(http.StatusBadRequest, errInvalidUserID)
+		return 0, false
+	}
+
+	return id, true
+}
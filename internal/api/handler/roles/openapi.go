@@ -0,0 +1,51 @@
+package roles
+
+import (
+	"net/http"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/openapi"
+)
+
+type idParams struct {
+	ID int `uri:"id"`
+}
+
+// Operations describes routes of the handler
+func Operations() []openapi.Operation {
+	manage := []model.Permission{model.PermRolesManage}
+	roles := openapi.Object{"roles": []model.Role{}}
+
+	return []openapi.Operation{
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/roles",
+			Tag:         "roles",
+			Summary:     "List roles with their permissions",
+			Auth:        true,
+			Permissions: manage,
+			Response:    roles,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/users/:id/roles",
+			Tag:         "roles",
+			Summary:     "List roles of a user",
+			Auth:        true,
+			Permissions: manage,
+			Params:      idParams{},
+			Response:    roles,
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/api/users/:id/roles",
+			Tag:         "roles",
+			Summary:     "Replace roles of a user, they apply when the user's access token is refreshed",
+			Auth:        true,
+			Permissions: manage,
+			Params:      idParams{},
+			Request:     setRolesRequest{},
+			Response:    roles,
+		},
+	}
+}
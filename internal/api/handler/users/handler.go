@@ -0,0 +1,254 @@
+package users
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/handler/auth"
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/service"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultLimit = 20
+
+var (
+	errInvalidRequestBody  = errors.New(errors.Invalid, "invalid request body")
+	errInvalidRequestQuery = errors.New(errors.Invalid, "invalid request query")
+	errInvalidUserID       = errors.New(errors.Invalid, "invalid user id")
+)
+
+type Handler struct {
+	users service.Service
+	audit service.Auditor
+	log   *logrus.Logger
+}
+
+func NewHandler(log *logrus.Logger, serv service.Service, auditor service.Auditor) *Handler {
+	return &Handler{
+		users: serv,
+		audit: auditor,
+		log:   log,
+	}
+}
+
+// user is model.User without credentials
+type user struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email,omitempty"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// auditedUser marks password changes, the password itself isn't audited
+type auditedUser struct {
+	user
+	Password string `json:"password,omitempty"`
+}
+
+type listQuery struct {
+	Name     string `form:"name"`
+	Username string `form:"username"`
+	Sort     string `form:"sort"   binding:"omitempty,oneof=id -id name -name username -username created_at -created_at"`
+	Limit    int    `form:"limit"  binding:"omitempty,min=1,max=100"`
+	Offset   int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type createRequest struct {
+	Name     string `json:"name"     binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email"    binding:"omitempty,email"`
+}
+
+// updateRequest holds changed fields only
+type updateRequest struct {
+	Name     *string `json:"name"     binding:"omitempty,min=1"`
+	Username *string `json:"username" binding:"omitempty,min=1"`
+	Password *string `json:"password" binding:"omitempty,min=1"`
+	Email    *string `json:"email"    binding:"omitempty,email"` // empty removes the email
+}
+
+func newUser(u model.User) user {
+	return user{
+		ID:            u.ID,
+		Name:          u.Name,
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		CreatedAt:     u.CreatedAt,
+	}
+}
+
+func (h *Handler) List(c *gin.Context) {
+	var query listQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestQuery).SetMeta(err.Error())
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultLimit
+	}
+
+	found, total, err := h.users.GetUsers(model.UserFilter{
+		Name:     query.Name,
+		Username: query.Username,
+		Sort:     query.Sort,
+		Limit:    query.Limit,
+		Offset:   query.Offset,
+	})
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	users := make([]user, 0, len(found))
+	for i := range found {
+		users = append(users, newUser(found[i]))
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"users":  users,
+		"total":  total,
+		"limit":  query.Limit,
+		"offset": query.Offset,
+	})
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.users.GetUserByID(id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newUser(u))
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var input createRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody).SetMeta(err.Error())
+		return
+	}
+
+	id, err := h.users.CreateUser(c.Request.Context(), model.User{
+		Name:     input.Name,
+		Username: input.Username,
+		Password: input.Password,
+		Email:    input.Email,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	if u, err := h.users.GetUserByID(id); err != nil {
+		requestid.Entry(c.Request.Context(), h.log).Errorf("Can't audit creation of user %d: %s", id, err)
+	} else {
+		event := auth.AuditEvent(c, model.AuditUserCreate, audit.Target("users", id))
+		event.Before, event.After = audit.Diff(nil, newUser(u))
+		h.audit.Audit(event)
+	}
+
+	c.JSON(http.StatusCreated, map[string]interface{}{
+		"id": id,
+	})
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	var input updateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithError(http.StatusBadRequest, errInvalidRequestBody).SetMeta(err.Error())
+		return
+	}
+
+	before, err := h.users.GetUserByID(id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	if err := h.users.UpdateUser(c.Request.Context(), id, model.UserUpdate{
+		Name:     input.Name,
+		Username: input.Username,
+		Password: input.Password,
+		Email:    input.Email,
+	}); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	u, err := h.users.GetUserByID(id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	after := auditedUser{user: newUser(u)}
+	if input.Password != nil {
+		after.Password = "changed"
+	}
+
+	event := auth.AuditEvent(c, model.AuditUserUpdate, audit.Target("users", id))
+	event.Before, event.After = audit.Diff(newUser(before), after)
+	h.audit.Audit(event)
+
+	c.JSON(http.StatusOK, newUser(u))
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.users.GetUserByID(id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	if err := h.users.DeleteUser(id); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	event := auth.AuditEvent(c, model.AuditUserDelete, audit.Target("users", id))
+	event.Before, event.After = audit.Diff(newUser(before), nil)
+	h.audit.Audit(event)
+
+	c.Status(http.StatusNoContent)
+}
+
+func userID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.AbortWithError(http.StatusBadRequest, errInvalidUserID)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func abortWithError(c *gin.Context, err error) {
+	c.AbortWithError(errors.Status(err), err)
+}
@@ -0,0 +1,214 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/service"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memService struct {
+	service.Service
+	users  []*model.User // by id, nil once deleted
+	filter model.UserFilter
+}
+
+func (m *memService) GetUsers(filter model.UserFilter) ([]model.User, int, error) {
+	m.filter = filter
+
+	var users []model.User
+	for _, u := range m.users {
+		if u != nil {
+			users = append(users, *u)
+		}
+	}
+
+	return users, len(users), nil
+}
+
+func (m *memService) GetUserByID(id int) (model.User, error) {
+	if id > len(m.users) || m.users[id-1] == nil {
+		return model.User{}, model.ErrUserNotFound
+	}
+
+	return *m.users[id-1], nil
+}
+
+func (m *memService) CreateUser(_ context.Context, user model.User) (int, error) {
+	for _, u := range m.users {
+		if u != nil && u.Username == user.Username {
+			return 0, model.ErrUsernameTaken
+		}
+	}
+
+	user.ID = len(m.users) + 1
+	m.users = append(m.users, &user)
+
+	return user.ID, nil
+}
+
+func (m *memService) UpdateUser(_ context.Context, id int, update model.UserUpdate) error {
+	u, err := m.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+
+	if update.Name != nil {
+		u.Name = *update.Name
+	}
+
+	if update.Password != nil {
+		u.Password = *update.Password
+	}
+
+	m.users[id-1] = &u
+
+	return nil
+}
+
+func (m *memService) DeleteUser(id int) error {
+	if _, err := m.GetUserByID(id); err != nil {
+		return err
+	}
+
+	m.users[id-1] = nil
+
+	return nil
+}
+
+type memAuditor struct {
+	service.Auditor
+	events []model.AuditEvent
+}
+
+func (m *memAuditor) Audit(event model.AuditEvent) {
+	m.events = append(m.events, event)
+}
+
+func newTestRouter(t *testing.T) (*gin.Engine, *memService, *memAuditor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log, _ := test.NewNullLogger()
+	serv := &memService{users: []*model.User{{ID: 1, Name: "Alice", Username: "alice", Password: "hash"}}}
+	auditor := &memAuditor{}
+	h := NewHandler(log, serv, auditor)
+
+	router := gin.New()
+	router.GET("/users", h.List)
+	router.POST("/users", h.Create)
+	router.GET("/users/:id", h.Get)
+	router.PATCH("/users/:id", h.Update)
+	router.DELETE("/users/:id", h.Delete)
+
+	return router, serv, auditor
+}
+
+func call(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", gin.MIMEJSON)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestList(t *testing.T) {
+	router, serv, _ := newTestRouter(t)
+
+	w := call(router, http.MethodGet, "/users", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"users": [{"id": 1, "name": "Alice", "username": "alice", "email_verified": false,
+		"created_at": "0001-01-01T00:00:00Z"}], "total": 1, "limit": 20, "offset": 0}`, w.Body.String())
+	assert.Equal(t, model.UserFilter{Limit: defaultLimit}, serv.filter)
+
+	w = call(router, http.MethodGet, "/users?name=al&limit=5&offset=10&sort=-created_at", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, model.UserFilter{Name: "al", Sort: "-created_at", Limit: 5, Offset: 10}, serv.filter)
+
+	for _, field := range model.UserSortFields {
+		for _, sort := range []string{field, "-" + field} {
+			assert.Equal(t, http.StatusOK, call(router, http.MethodGet, "/users?sort="+sort, "").Code, sort)
+		}
+	}
+
+	for _, query := range []string{"sort=password_hash", "sort=name%3Bdrop", "limit=101", "limit=-1", "offset=-1", "limit=x"} {
+		assert.Equal(t, http.StatusBadRequest, call(router, http.MethodGet, "/users?"+query, "").Code, query)
+	}
+}
+
+func TestGet(t *testing.T) {
+	router, _, _ := newTestRouter(t)
+
+	w := call(router, http.MethodGet, "/users/1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "hash", "credentials aren't returned")
+
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodGet, "/users/2", "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(router, http.MethodGet, "/users/0", "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(router, http.MethodGet, "/users/x", "").Code)
+}
+
+func TestCreate(t *testing.T) {
+	router, _, auditor := newTestRouter(t)
+
+	w := call(router, http.MethodPost, "/users", `{"name": "Bob", "username": "bob", "password": "secret"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"id": 2}`, w.Body.String())
+
+	require.Len(t, auditor.events, 1)
+	assert.Equal(t, model.AuditUserCreate, auditor.events[0].Action)
+	assert.NotContains(t, string(auditor.events[0].After), "secret")
+
+	assert.Equal(t, http.StatusConflict,
+		call(router, http.MethodPost, "/users", `{"name": "Bob", "username": "bob", "password": "secret"}`).Code)
+
+	for _, body := range []string{
+		`{"name": "Carol", "username": "carol"}`,
+		`{"name": "Carol", "username": "carol", "password": "secret", "email": "carol"}`,
+		`{"name": "Carol"`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, call(router, http.MethodPost, "/users", body).Code, body)
+	}
+
+	assert.Len(t, auditor.events, 1, "failures aren't audited")
+}
+
+func TestUpdate(t *testing.T) {
+	router, _, auditor := newTestRouter(t)
+
+	w := call(router, http.MethodPatch, "/users/1", `{"name": "Alice B", "password": "secret"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var updated user
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "Alice B", updated.Name)
+
+	require.Len(t, auditor.events, 1)
+	assert.JSONEq(t, `{"name": "Alice B", "password": "changed"}`, string(auditor.events[0].After))
+
+	assert.Equal(t, http.StatusBadRequest, call(router, http.MethodPatch, "/users/1", `{"name": ""}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(router, http.MethodPatch, "/users/x", `{}`).Code)
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodPatch, "/users/2", `{"name": "Bob"}`).Code)
+}
+
+func TestDelete(t *testing.T) {
+	router, _, auditor := newTestRouter(t)
+
+	assert.Equal(t, http.StatusNoContent, call(router, http.MethodDelete, "/users/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodDelete, "/users/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(router, http.MethodGet, "/users/1", "").Code)
+
+	require.Len(t, auditor.events, 1)
+	assert.Equal(t, model.AuditUserDelete, auditor.events[0].Action)
+}
@@ -0,0 +1,77 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/openapi"
+)
+
+type idParams struct {
+	ID int `uri:"id"`
+}
+
+// Operations describes routes of the handler
+func Operations() []openapi.Operation {
+	read, write := []model.Permission{model.PermUsersRead}, []model.Permission{model.PermUsersWrite}
+
+	return []openapi.Operation{
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/users",
+			Tag:         "users",
+			Summary:     "List users",
+			Auth:        true,
+			Permissions: read,
+			Params:      listQuery{},
+			Response: openapi.Object{
+				"users":  []user{},
+				"total":  0,
+				"limit":  0,
+				"offset": 0,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/api/users",
+			Tag:         "users",
+			Summary:     "Create a user",
+			Auth:        true,
+			Permissions: write,
+			Request:     createRequest{},
+			Status:      http.StatusCreated,
+			Response:    openapi.Object{"id": 0},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/users/:id",
+			Tag:         "users",
+			Summary:     "Get a user",
+			Auth:        true,
+			Permissions: read,
+			Params:      idParams{},
+			Response:    user{},
+		},
+		{
+			Method:      http.MethodPatch,
+			Path:        "/api/users/:id",
+			Tag:         "users",
+			Summary:     "Change given fields of a user",
+			Auth:        true,
+			Permissions: write,
+			Params:      idParams{},
+			Request:     updateRequest{},
+			Response:    user{},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/api/users/:id",
+			Tag:         "users",
+			Summary:     "Delete a user",
+			Auth:        true,
+			Permissions: write,
+			Params:      idParams{},
+			Status:      http.StatusNoContent,
+		},
+	}
+}
@@ -0,0 +1,217 @@
+// Package lockout slows down password guessing. Failed attempts are counted per key, like username or client IP,
+// once a key exceeds allowed attempts every next attempt has to wait twice as long as the previous one,
+// and when the delay reaches the maximum the key is locked out for that long.
+package lockout
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAttempts   = 5
+	defaultIPAttempts = 20
+	defaultBaseDelay  = time.Second
+	defaultMaxDelay   = 15 * time.Minute
+	sweepInterval     = time.Minute
+
+	userPrefix = "user:"
+	ipPrefix   = "ip:"
+)
+
+// Actions of events
+const (
+	Locked   = "lockout"
+	Unlocked = "unlock"
+)
+
+type Config struct {
+	Attempts   int           `mapstructure:"attempts"`    // failures per username before backoff starts
+	IPAttempts int           `mapstructure:"ip_attempts"` // failures per client IP before backoff starts
+	BaseDelay  time.Duration `mapstructure:"base_delay"`  // delay after the first failure beyond allowed ones
+	MaxDelay   time.Duration `mapstructure:"max_delay"`   // lockout duration, failures are forgotten after it passes
+}
+
+// Event tells that the key is locked out until the time or unlocked
+type Event struct {
+	Action string
+	Key    string
+	Until  time.Time
+}
+
+type entry struct {
+	failures int
+	last     time.Time // of the last failure
+	until    time.Time // attempts are refused until then
+	locked   bool      // the delay reached the maximum
+}
+
+type Limiter struct {
+	config    Config
+	onEvent   func(Event)
+	now       func() time.Time
+	mutex     sync.Mutex
+	entries   map[string]*entry
+	pending   []Event // emitted after the lock is released
+	lastSweep time.Time
+}
+
+// New returns limiter reporting lockouts to onEvent
+func New(c Config, onEvent func(Event)) *Limiter {
+	if c.Attempts == 0 {
+		c.Attempts = defaultAttempts
+	}
+
+	if c.IPAttempts == 0 {
+		c.IPAttempts = defaultIPAttempts
+	}
+
+	if c.BaseDelay == 0 {
+		c.BaseDelay = defaultBaseDelay
+	}
+
+	if c.MaxDelay == 0 {
+		c.MaxDelay = defaultMaxDelay
+	}
+
+	if onEvent == nil {
+		onEvent = func(Event) {}
+	}
+
+	return &Limiter{
+		config:  c,
+		onEvent: onEvent,
+		now:     time.Now,
+		entries: make(map[string]*entry),
+	}
+}
+
+// UserKey makes key of the username
+func UserKey(username string) string { return userPrefix + username }
+
+// IPKey makes key of the client IP, IPs are allowed more attempts as users behind NAT share them
+func IPKey(ip string) string { return ipPrefix + ip }
+
+// Check returns how long the caller has to wait before the next attempt, zero if it's allowed now
+func (l *Limiter) Check(keys ...string) time.Duration {
+	var wait time.Duration
+
+	l.update(func(now time.Time) {
+		for _, key := range keys {
+			if e, ok := l.entries[key]; ok && e.until.Sub(now) > wait {
+				wait = e.until.Sub(now)
+			} else if ok {
+				l.lift(key, e, now)
+			}
+		}
+	})
+
+	return wait
+}
+
+// Fail counts a failed attempt of every key
+func (l *Limiter) Fail(keys ...string) {
+	l.update(func(now time.Time) {
+		for _, key := range keys {
+			e, ok := l.entries[key]
+			if !ok {
+				e = &entry{}
+				l.entries[key] = e
+			}
+
+			l.lift(key, e, now)
+			e.failures++
+			e.last = now
+
+			delay := l.delay(key, e.failures)
+			if delay == 0 {
+				continue
+			}
+
+			e.until = now.Add(delay)
+			if delay == l.config.MaxDelay && !e.locked {
+				e.locked = true
+				l.pending = append(l.pending, Event{Action: Locked, Key: key, Until: e.until})
+			}
+		}
+	})
+}
+
+// Succeed forgets failures of the keys
+func (l *Limiter) Succeed(keys ...string) {
+	l.update(func(time.Time) {
+		for _, key := range keys {
+			if e, ok := l.entries[key]; ok {
+				l.forget(key, e)
+			}
+		}
+	})
+}
+
+// delay is zero for allowed attempts and doubles with every next one up to the maximum
+func (l *Limiter) delay(key string, failures int) time.Duration {
+	allowed := l.config.Attempts
+	if strings.HasPrefix(key, ipPrefix) {
+		allowed = l.config.IPAttempts
+	}
+
+	if failures <= allowed {
+		return 0
+	}
+
+	delay := l.config.BaseDelay
+	for i := allowed + 1; i < failures && delay < l.config.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > l.config.MaxDelay {
+		delay = l.config.MaxDelay
+	}
+
+	return delay
+}
+
+// update runs fn under the lock and emits events it caused after,
+// once in a while failures are forgotten if the maximum delay passed since the last one
+func (l *Limiter) update(fn func(now time.Time)) {
+	l.mutex.Lock()
+
+	now := l.now()
+
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.lastSweep = now
+		for key, e := range l.entries {
+			if l.lift(key, e, now); now.Sub(e.last) >= l.config.MaxDelay {
+				l.forget(key, e)
+			}
+		}
+	}
+
+	fn(now)
+
+	events := l.pending
+	l.pending = nil
+
+	l.mutex.Unlock()
+
+	for _, event := range events {
+		l.onEvent(event)
+	}
+}
+
+// lift unlocks the key if its lockout is over, failures are kept, so the next one locks it out again
+func (l *Limiter) lift(key string, e *entry, now time.Time) {
+	if e.locked && !now.Before(e.until) {
+		e.locked = false
+		l.pending = append(l.pending, Event{Action: Unlocked, Key: key})
+	}
+}
+
+func (l *Limiter) forget(key string, e *entry) {
+	if e.locked {
+		l.pending = append(l.pending, Event{Action: Unlocked, Key: key})
+	}
+
+	delete(l.entries, key)
+}
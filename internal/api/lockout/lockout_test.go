@@ -0,0 +1,59 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestLimiter(t *testing.T) {
+	var events []Event
+	c := &clock{now: time.Unix(1e9, 0)}
+	l := New(Config{Attempts: 2, IPAttempts: 10, BaseDelay: time.Second, MaxDelay: 8 * time.Second}, func(e Event) {
+		events = append(events, e)
+	})
+	l.now = c.Now
+
+	user, ip := UserKey("alice"), IPKey("10.0.0.1")
+
+	l.Fail(user, ip)
+	l.Fail(user, ip)
+	assert.Zero(t, l.Check(user, ip), "allowed attempts aren't delayed")
+
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second} {
+		l.Fail(user, ip)
+		assert.Equal(t, want, l.Check(user, ip))
+		assert.Zero(t, l.Check(ip), "IPs are allowed more attempts")
+		c.Advance(want)
+	}
+
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, Event{Action: Locked, Key: user, Until: c.now}, events[0])
+	}
+
+	assert.Zero(t, l.Check(user))
+	assert.Equal(t, Event{Action: Unlocked, Key: user}, events[len(events)-1], "expired lockout is lifted")
+
+	l.Fail(user)
+	assert.Equal(t, 8*time.Second, l.Check(user), "next failure locks out again")
+	assert.Equal(t, Locked, events[len(events)-1].Action)
+
+	l.Succeed(user)
+	assert.Zero(t, l.Check(user))
+	assert.Equal(t, Event{Action: Unlocked, Key: user}, events[len(events)-1])
+
+	l.Fail(user)
+	c.Advance(time.Minute)
+	l.Fail(user)
+	assert.Len(t, l.entries, 1, "old failures are forgotten")
+	assert.Equal(t, 1, l.entries[user].failures)
+}
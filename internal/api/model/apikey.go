@@ -0,0 +1,57 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, it tells keys from JWTs
+const APIKeyPrefix = "tro_"
+
+// APIKey is a long-lived credential of a user, it's stored by hash.
+// The key has those permissions of its scopes which the user still has.
+type APIKey struct {
+	ID         int        `json:"id"                     db:"id"`
+	UserID     int        `json:"-"                      db:"user_id"`
+	Name       string     `json:"name"                   db:"name"`
+	Prefix     string     `json:"prefix"                 db:"prefix"` // start of the key to recognize it by
+	Hash       string     `json:"-"                      db:"key_hash"`
+	Scopes     Scopes     `json:"scopes"                 db:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"             db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+// Scopes are stored as comma separated permissions
+type Scopes []Permission
+
+func (s Scopes) Value() (driver.Value, error) {
+	values := make([]string, 0, len(s))
+	for _, p := range s {
+		values = append(values, string(p))
+	}
+
+	return strings.Join(values, ","), nil
+}
+
+func (s *Scopes) Scan(src interface{}) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("can't scan %T into scopes", src)
+	}
+
+	*s = Scopes{}
+	for _, p := range strings.Split(value, ",") {
+		if p != "" {
+			*s = append(*s, Permission(p))
+		}
+	}
+
+	return nil
+}
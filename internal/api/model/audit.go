@@ -0,0 +1,99 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Audited actions, RPC calls are audited by method name, e.g. "operators.create"
+const (
+	AuditSignUp        = "auth.sign_up"
+	AuditSignIn        = "auth.sign_in"
+	AuditSignInFailed  = "auth.sign_in_failed"
+	AuditLockout       = "auth.lockout"
+	AuditUnlock        = "auth.unlock"
+	AuditImpersonate   = "auth.impersonate"
+	AuditUserCreate    = "users.create"
+	AuditUserUpdate    = "users.update"
+	AuditUserDelete    = "users.delete"
+	AuditUserRoles     = "users.roles"
+	AuditSessionRevoke = "sessions.revoke"
+	AuditAPIKeyCreate  = "api_keys.create"
+	AuditAPIKeyRevoke  = "api_keys.revoke"
+)
+
+// MaxAuditTarget is the length of audit_log.target column, longer targets (e.g. of long usernames) are cut
+const MaxAuditTarget = 100
+
+// AuditEvent is an entry of the append-only audit log. Target is "kind:id" of the changed object,
+// e.g. "users:12", changes are described by fields of the target which differ before and after.
+type AuditEvent struct {
+	ID             int       `json:"id"                        db:"id"`
+	Time           time.Time `json:"time"                      db:"created_at"`
+	ActorID        int       `json:"actor_id,omitempty"        db:"actor_id"` // 0 for anonymous requests and the system
+	ImpersonatorID int       `json:"impersonator_id,omitempty" db:"impersonator_id"`
+	Action         string    `json:"action"                    db:"action"`
+	Target         string    `json:"target,omitempty"          db:"target"`
+	Before         Snapshot  `json:"before,omitempty"          db:"before_state"`
+	After          Snapshot  `json:"after,omitempty"           db:"after_state"`
+	IP             string    `json:"ip,omitempty"              db:"ip"`
+	RequestID      string    `json:"request_id,omitempty"      db:"request_id"`
+}
+
+// AuditFilter selects audit events, zero fields don't filter
+type AuditFilter struct {
+	ActorID int
+	Action  string // exact action or its prefix ending with ".", e.g. "auth."
+	Target  string
+	From    time.Time // inclusive
+	To      time.Time // exclusive
+	Limit   int
+	Offset  int
+}
+
+// Snapshot is JSON object of audited fields, stored as text
+type Snapshot json.RawMessage
+
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	if len(s) == 0 {
+		return []byte("null"), nil
+	}
+
+	return s, nil
+}
+
+func (s *Snapshot) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*s = nil
+		return nil
+	}
+
+	*s = append((*s)[:0], b...)
+
+	return nil
+}
+
+func (s Snapshot) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	return string(s), nil
+}
+
+func (s *Snapshot) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+	case string:
+		*s = Snapshot(v)
+	case []byte:
+		*s = append(Snapshot(nil), v...) // drivers may reuse the buffer
+	default:
+		return fmt.Errorf("can't scan %T into snapshot", src)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package model
+
+import "github.com/dmytro-vovk/tro/internal/errors"
+
+var (
+	ErrUserNotFound       = errors.New(errors.NotFound, "user not found")
+	ErrUsernameTaken      = errors.New(errors.Conflict, "username is already taken")
+	ErrEmailTaken         = errors.New(errors.Conflict, "email is already taken")
+	ErrInvalidCredentials = errors.New(errors.Unauthorized, "invalid username or password")
+	ErrInvalidToken       = errors.New(errors.Unauthorized, "invalid or expired token")
+	ErrTokenNotFound      = errors.New(errors.NotFound, "token not found")
+	ErrTOTPNotEnrolled    = errors.New(errors.NotFound, "two-factor authentication is not enrolled")
+	ErrTOTPEnabled        = errors.New(errors.Conflict, "two-factor authentication is already enabled")
+	ErrInvalidCode        = errors.New(errors.Invalid, "invalid verification code")
+	ErrUnsupported        = errors.New(errors.Unsupported, "not supported by the authentication method")
+	ErrAuthNotConfigured  = errors.New(errors.Unavailable, "authentication provider is not configured")
+	ErrSignUpDisabled     = errors.New(errors.Forbidden, "sign-up is disabled, users are managed by the identity provider")
+	ErrLocalUser          = errors.New(errors.Forbidden, "username belongs to a local user")
+	ErrRoleNotFound       = errors.New(errors.NotFound, "role not found")
+	ErrAPIKeyNotFound     = errors.New(errors.NotFound, "API key not found")
+	ErrSessionNotFound    = errors.New(errors.NotFound, "session not found")
+	ErrImpersonation      = errors.New(errors.Forbidden, "impersonation not allowed")
+	ErrUnknownPermission  = errors.New(errors.Invalid, "unknown permission")
+	ErrOperatorNotFound   = errors.New(errors.NotFound, "operator not found")
+	ErrOperatorLoginTaken = errors.New(errors.Conflict, "operator login is already taken")
+)
@@ -0,0 +1,7 @@
+package model
+
+type Operator struct {
+	ID       int    `json:"id"       db:"id"`
+	Login    string `json:"login"    db:"login"`
+	Disabled bool   `json:"disabled" db:"disabled"`
+}
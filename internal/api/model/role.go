@@ -0,0 +1,49 @@
+package model
+
+// Permission allows a group of actions, roles are sets of permissions
+type Permission string
+
+const (
+	PermUsersRead      Permission = "users:read"
+	PermUsersWrite     Permission = "users:write"
+	PermOperatorsRead  Permission = "operators:read"
+	PermOperatorsWrite Permission = "operators:write"
+	PermRolesManage    Permission = "roles:manage"
+	// PermUsersImpersonate allows acting as users having no more permissions than the impersonator
+	PermUsersImpersonate Permission = "users:impersonate"
+	PermAuditRead        Permission = "audit:read"
+)
+
+// Permissions are all known permissions
+var Permissions = []Permission{
+	PermUsersRead, PermUsersWrite, PermOperatorsRead, PermOperatorsWrite, PermRolesManage, PermUsersImpersonate, PermAuditRead,
+}
+
+// RoleAdmin is the role having all permissions, the grant-admin command assigns it
+const RoleAdmin = "admin"
+
+type Role struct {
+	ID          int          `json:"id"   db:"id"`
+	Name        string       `json:"name" db:"name"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// RolePermissions returns names of the roles and union of their permissions
+func RolePermissions(roles []Role) ([]string, []Permission) {
+	names := make([]string, 0, len(roles))
+	seen := make(map[Permission]bool)
+
+	var permissions []Permission
+	for _, r := range roles {
+		names = append(names, r.Name)
+
+		for _, p := range r.Permissions {
+			if !seen[p] {
+				seen[p] = true
+				permissions = append(permissions, p)
+			}
+		}
+	}
+
+	return names, permissions
+}
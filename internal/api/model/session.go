@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Device is what a session is started from, the name is given by the client or guessed from the user agent
+type Device struct {
+	Name      string `json:"device"     db:"device"`
+	UserAgent string `json:"user_agent" db:"user_agent"`
+	IP        string `json:"ip"         db:"ip"` // of the last sign-in or refresh
+}
+
+// Session is a single sign-in, its id is the refresh token family
+type Session struct {
+	ID     string `json:"id" db:"family"`
+	UserID int    `json:"-"  db:"user_id"`
+	Device
+	CreatedAt  time.Time `json:"created_at"   db:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`
+	Current    bool      `json:"current"      db:"-"` // the session of the request
+}
+
+// Revocation tells which sessions ended, all sessions of the user if SessionID is empty,
+// or which API key is revoked if APIKeyID is set
+type Revocation struct {
+	UserID    int
+	SessionID string
+	APIKeyID  int
+}
@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// RefreshToken is stored by hash, tokens issued one after another by refreshing share the family,
+// which is the session of a single sign-in
+type RefreshToken struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	Family    string     `db:"family"`
+	Hash      string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	UsedAt    *time.Time `db:"used_at"`    // set when exchanged for a new token
+	RevokedAt *time.Time `db:"revoked_at"` // set on logout or reuse
+}
+
+// Tokens are issued on sign-in and refresh
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"` // not issued on impersonation
+	ExpiresIn    int    `json:"expires_in"`              // access token lifetime in seconds
+	// MFAToken is set instead of other fields when the password is right, but a second factor is required,
+	// it's exchanged for tokens along with a verification code
+	MFAToken string `json:"-"`
+	UserID   int    `json:"-"` // the tokens are issued to, e.g. to audit sign-ins
+}
+
+// Identity is who the access token is issued to
+type Identity struct {
+	UserID    int
+	SessionID string // refresh token family
+	APIKeyID  int    // set when authenticated by API key instead of session
+	// ImpersonatorID is the admin acting as the user, SessionID is the admin's session then
+	ImpersonatorID int
+	Roles          []string
+	Permissions    []Permission
+	ExpiresAt      time.Time // of the access token, zero when unknown, e.g. of API keys
+}
+
+// Can reports whether the identity has all the permissions
+func (i Identity) Can(permissions ...Permission) bool {
+	for _, required := range permissions {
+		found := false
+		for _, p := range i.Permissions {
+			if p == required {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Purposes of user tokens
+const (
+	TokenPasswordReset = "password_reset"
+	TokenVerifyEmail   = "verify_email"
+)
+
+// UserToken is a single-use token mailed to the user, it's stored by hash
+type UserToken struct {
+	UserID    int       `db:"user_id"`
+	Purpose   string    `db:"purpose"`
+	Hash      string    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// TOTP is the authenticator secret of a user, it's pending until the user proves the app is set up
+type TOTP struct {
+	UserID    int        `db:"user_id"`
+	Secret    string     `db:"secret"`
+	EnabledAt *time.Time `db:"enabled_at"` // nil while enrolment is pending
+	LastStep  int64      `db:"last_step"`  // time step of the last accepted code, codes are accepted once
+}
+
+// TOTPEnrolment is shown to the user to set up an authenticator app
+type TOTPEnrolment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth:// key URI
+}
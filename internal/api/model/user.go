@@ -1,13 +1,52 @@
 package model
 
 import (
+	"strings"
 	"time"
 )
 
 type User struct {
 	ID        int       `json:"-"          db:"id"`
-	Name      string    `json:"name"       binding:"required"`
-	Username  string    `json:"username"   binding:"required"` // for QR
-	Password  string    `json:"password"   binding:"required"`
-	CreatedAt time.Time `json:"created_at" binding:"required"`
+	Name      string    `json:"name"       db:"name"          binding:"required"`
+	Username  string    `json:"username"   db:"username"      binding:"required"` // for QR
+	Password  string    `json:"password"   db:"password_hash" binding:"required"`
+	Email     string    `json:"email"      db:"email"         binding:"omitempty,email"` // optional, needed for password reset
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	EmailVerifiedAt *time.Time `json:"-" db:"email_verified_at"`
+	// Subject is the id of the user at the external identity provider, empty for local users
+	Subject string `json:"-" db:"subject"`
+}
+
+// UserUpdate holds changed fields only, nil fields are left as is,
+// changing the email makes it unverified
+type UserUpdate struct {
+	Name     *string
+	Username *string
+	Password *string
+	Email    *string
+}
+
+// UserFilter selects a page of users
+type UserFilter struct {
+	Name     string // substring of the name
+	Username string // substring of the username
+	Sort     string // one of UserSortFields, prefixed with "-" for descending order
+	Limit    int
+	Offset   int
+}
+
+// UserSortFields are fields users can be sorted by
+var UserSortFields = []string{"id", "name", "username", "created_at"}
+
+// Order returns validated sort field, users are sorted by id by default
+func (f UserFilter) Order() (field string, desc bool) {
+	field = strings.TrimPrefix(f.Sort, "-")
+	for _, allowed := range UserSortFields {
+		if field == allowed {
+			return field, strings.HasPrefix(f.Sort, "-")
+		}
+	}
+
+	return "id", false
 }
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/dmytro-vovk/tro/internal/api/handler/apikeys"
+	"github.com/dmytro-vovk/tro/internal/api/handler/audit"
+	"github.com/dmytro-vovk/tro/internal/api/handler/auth"
+	"github.com/dmytro-vovk/tro/internal/api/handler/middleware"
+	"github.com/dmytro-vovk/tro/internal/api/handler/roles"
+	"github.com/dmytro-vovk/tro/internal/api/handler/users"
+	"github.com/dmytro-vovk/tro/internal/api/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// spec describes routes under /auth and /api, TestSpec fails when they diverge
+func spec() *openapi.Document {
+	return openapi.New(openapi.Info{
+		Title:       "ТРО",
+		Version:     "1.0",
+		Description: `Failed requests respond with {"error": {...}}, authenticated ones take "Authorization: Bearer <token>"`,
+	}, middleware.ErrorResponse{}).
+		Add(auth.Operations()...).
+		Add(users.Operations()...).
+		Add(roles.Operations()...).
+		Add(apikeys.Operations()...).
+		Add(audit.Operations()...).
+		Add(
+			openapi.Operation{
+				Method:   http.MethodGet,
+				Path:     "/api/hello-world",
+				Tag:      "misc",
+				Summary:  "Say hello",
+				Response: openapi.Object{"message": ""},
+			},
+			openapi.Operation{
+				Method:   http.MethodGet,
+				Path:     "/api/openapi.json",
+				Tag:      "misc",
+				Summary:  "This document",
+				Response: openapi.Object{},
+			},
+		)
+}
+
+func (h *Handler) openAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, spec())
+}
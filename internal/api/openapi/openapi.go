@@ -0,0 +1,215 @@
+// Package openapi describes the REST API as OpenAPI 3 document, schemas are derived from Go types
+// by their json, form, uri and binding tags the same way gin binds them
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+)
+
+const (
+	version        = "3.0.3"
+	securityScheme = "bearerAuth"
+	errorResponse  = "Error"
+)
+
+// Operation is a route of the API, Path is as registered in gin, e.g. /api/users/:id
+type Operation struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Auth        bool               // requires access token or API key
+	Permissions []model.Permission // required ones
+	Params      interface{}        // struct with uri tags for path and form tags for query parameters
+	Request     interface{}        // JSON body
+	Status      int                // of success, 200 by default
+	Response    interface{}        // JSON body of success, nil when there is none
+}
+
+// Object is JSON object of the values, it describes responses made of maps
+type Object map[string]interface{}
+
+// OneOf is a body which is one of the values
+type OneOf []interface{}
+
+// Document is OpenAPI document, it's served as JSON
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components components                      `json:"components"`
+
+	types map[string]bool
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema       `json:"schemas"`
+	Responses       map[string]response      `json:"responses"`
+	SecuritySchemes map[string]securityEntry `json:"securitySchemes"`
+}
+
+type securityEntry struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+}
+
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// New makes a document with errorBody being the body of all failed requests
+func New(info Info, errorBody interface{}) *Document {
+	d := &Document{
+		OpenAPI: version,
+		Info:    info,
+		Paths:   make(map[string]map[string]operation),
+		Components: components{
+			Schemas:   make(map[string]*Schema),
+			Responses: make(map[string]response),
+			SecuritySchemes: map[string]securityEntry{
+				securityScheme: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "Access token or API key, API keys start with " + model.APIKeyPrefix,
+				},
+			},
+		},
+		types: make(map[string]bool),
+	}
+
+	d.Components.Responses[errorResponse] = response{
+		Description: "Request failed",
+		Content:     jsonContent(d.schema(errorBody)),
+	}
+
+	return d
+}
+
+// Add describes the operations, added ones are replaced
+func (d *Document) Add(operations ...Operation) *Document {
+	for _, o := range operations {
+		path, names := pathOf(o.Path)
+		if d.Paths[path] == nil {
+			d.Paths[path] = make(map[string]operation)
+		}
+
+		d.Paths[path][strings.ToLower(o.Method)] = d.operation(o, names)
+	}
+
+	return d
+}
+
+func (d *Document) operation(o Operation, pathParams []string) operation {
+	op := operation{
+		Summary:    o.Summary,
+		Parameters: d.parameters(o.Params, pathParams),
+		Responses: map[string]response{
+			"default": {Ref: "#/components/responses/" + errorResponse},
+		},
+	}
+
+	if o.Tag != "" {
+		op.Tags = []string{o.Tag}
+	}
+
+	if o.Auth {
+		op.Security = []map[string][]string{{securityScheme: {}}}
+	}
+
+	if len(o.Permissions) > 0 {
+		names := make([]string, 0, len(o.Permissions))
+		for _, p := range o.Permissions {
+			names = append(names, string(p))
+		}
+
+		op.Description = "Requires permissions: " + strings.Join(names, ", ")
+	}
+
+	if o.Request != nil {
+		op.RequestBody = &requestBody{
+			Required: true,
+			Content:  jsonContent(d.schema(o.Request)),
+		}
+	}
+
+	status := o.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := response{Description: http.StatusText(status)}
+	if o.Response != nil {
+		success.Content = jsonContent(d.schema(o.Response))
+	}
+
+	op.Responses[strconv.Itoa(status)] = success
+
+	return op
+}
+
+var pathParam = regexp.MustCompile(`[:*](\w+)`)
+
+// pathOf converts gin path to OpenAPI one, returning names of its parameters
+func pathOf(ginPath string) (string, []string) {
+	var names []string
+	for _, m := range pathParam.FindAllStringSubmatch(ginPath, -1) {
+		names = append(names, m[1])
+	}
+
+	return pathParam.ReplaceAllString(ginPath, "{$1}"), names
+}
+
+// Path converts gin path to OpenAPI one, e.g. /api/users/:id to /api/users/{id}
+func Path(ginPath string) string {
+	path, _ := pathOf(ginPath)
+
+	return path
+}
+
+func jsonContent(s *Schema) map[string]mediaType {
+	return map[string]mediaType{
+		"application/json": {Schema: s},
+	}
+}
@@ -0,0 +1,88 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type base struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created_at"`
+}
+
+type item struct {
+	base
+	Name    string            `json:"name"  binding:"required,max=10"`
+	Kind    string            `json:"kind"  binding:"omitempty,oneof=a b"`
+	Email   *string           `json:"email" binding:"omitempty,email"`
+	Tags    []string          `json:"tags"  binding:"min=1"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Raw     json.RawMessage   `json:"raw"`
+	Secret  string            `json:"-"`
+	private string
+}
+
+type params struct {
+	ID    int    `uri:"id"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Query string `form:"q"   binding:"required"`
+}
+
+func TestDocument(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"}, struct {
+		Message string `json:"message"`
+	}{}).Add(Operation{
+		Method:   http.MethodPatch,
+		Path:     "/items/:id/:kind",
+		Auth:     true,
+		Params:   params{},
+		Request:  item{},
+		Response: OneOf{item{}, Object{"count": 0}},
+	})
+
+	op, ok := d.Paths["/items/{id}/{kind}"]["patch"]
+	require.True(t, ok)
+
+	assert.Equal(t, []parameter{
+		{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer", Format: "int32"}},
+		{Name: "kind", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "limit", In: "query", Schema: &Schema{Type: "integer", Format: "int32", Minimum: ptr(1), Maximum: ptr(100)}},
+		{Name: "q", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+	}, op.Parameters)
+
+	ref := &Schema{Ref: "#/components/schemas/openapi.item"}
+	assert.Equal(t, ref, op.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, &Schema{OneOf: []*Schema{ref, {
+		Type:       "object",
+		Properties: map[string]*Schema{"count": {Type: "integer", Format: "int32"}},
+		Required:   []string{"count"},
+	}}}, op.Responses["200"].Content["application/json"].Schema)
+	assert.Equal(t, []map[string][]string{{securityScheme: {}}}, op.Security)
+
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":         {Type: "integer", Format: "int32"},
+			"created_at": {Type: "string", Format: "date-time"},
+			"name":       {Type: "string", MaxLength: ptr(10)},
+			"kind":       {Type: "string", Enum: []string{"a", "b"}},
+			"email":      {Type: "string", Format: "email"},
+			"tags":       {Type: "array", Items: &Schema{Type: "string"}, MinItems: ptr(1)},
+			"labels":     {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			"raw":        {},
+		},
+		Required: []string{"name"},
+	}, d.Components.Schemas["openapi.item"])
+
+	_, err := json.Marshal(d)
+	require.NoError(t, err)
+}
+
+func ptr(n int) *int {
+	return &n
+}
@@ -0,0 +1,274 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is JSON schema of OpenAPI, empty one allows any value
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schema describes the value, named structs are referenced from components
+func (d *Document) schema(v interface{}) *Schema {
+	switch v := v.(type) {
+	case nil:
+		return &Schema{}
+	case Object:
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for name, value := range v {
+			s.Properties[name] = d.schema(value)
+			s.Required = append(s.Required, name)
+		}
+
+		sort.Strings(s.Required)
+
+		return s
+	case OneOf:
+		s := &Schema{}
+		for _, value := range v {
+			s.OneOf = append(s.OneOf, d.schema(value))
+		}
+
+		return s
+	}
+
+	return d.typeSchema(reflect.TypeOf(v))
+}
+
+func (d *Document) typeSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(marshalerType):
+		return &Schema{} // marshals itself, e.g. raw JSON
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"} // base64 encoded
+		}
+
+		return &Schema{Type: "array", Items: d.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+
+		// e.g. model.User, dots are allowed in component names
+		name := t.String()
+		if !d.types[name] {
+			d.types[name] = true // before fields are described, in case the type refers to itself
+			d.Components.Schemas[name] = d.structSchema(t)
+		}
+
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for _, f := range fields(t, "json") {
+		property := d.typeSchema(f.Type)
+		if property.Ref == "" {
+			constrain(property, f.binding)
+		}
+
+		s.Properties[f.name] = property
+		if f.required {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+
+	return s
+}
+
+// parameters describes path and query parameters, those of the path are strings unless described by params
+func (d *Document) parameters(params interface{}, path []string) []parameter {
+	described := make(map[string]parameter)
+
+	var query []parameter
+	if params != nil {
+		t := reflect.TypeOf(params)
+		for _, f := range fields(t, "uri") {
+			described[f.name] = parameter{Name: f.name, In: "path", Required: true, Schema: d.paramSchema(f)}
+		}
+
+		for _, f := range fields(t, "form") {
+			query = append(query, parameter{Name: f.name, In: "query", Required: f.required, Schema: d.paramSchema(f)})
+		}
+	}
+
+	var result []parameter
+	for _, name := range path {
+		p, ok := described[name]
+		if !ok {
+			p = parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		}
+
+		result = append(result, p)
+	}
+
+	return append(result, query...)
+}
+
+func (d *Document) paramSchema(f field) *Schema {
+	s := d.typeSchema(f.Type)
+	constrain(s, f.binding)
+
+	return s
+}
+
+type field struct {
+	reflect.StructField
+	name     string
+	required bool
+	binding  []string
+}
+
+// fields lists fields named by the tag, fields of embedded structs are promoted as encoding/json does
+func fields(t reflect.Type, tag string) []field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var result []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := strings.Split(f.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			result = append(result, fields(f.Type, tag)...)
+			continue
+		}
+
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+
+		if name == "" {
+			if tag != "json" {
+				continue // gin binds tagged fields only
+			}
+
+			name = f.Name
+		}
+
+		rules := strings.Split(f.Tag.Get("binding"), ",")
+		result = append(result, field{
+			StructField: f,
+			name:        name,
+			required:    hasRule(rules, "required"),
+			binding:     rules,
+		})
+	}
+
+	return result
+}
+
+func hasRule(rules []string, rule string) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// constrain applies validation rules of the binding tag
+func constrain(s *Schema, rules []string) {
+	for _, rule := range rules {
+		name, value := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, value = rule[:i], rule[i+1:]
+		}
+
+		switch name {
+		case "email":
+			s.Format = "email"
+		case "oneof":
+			s.Enum = strings.Fields(value)
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+
+			limit(s, name == "min", n)
+		}
+	}
+}
+
+// limit sets length of strings, value of numbers or size of arrays
+func limit(s *Schema, min bool, n int) {
+	var bound **int
+	switch {
+	case s.Type == "string" && min:
+		bound = &s.MinLength
+	case s.Type == "string":
+		bound = &s.MaxLength
+	case s.Type == "array" && min:
+		bound = &s.MinItems
+	case s.Type == "array":
+		bound = &s.MaxItems
+	case (s.Type == "integer" || s.Type == "number") && min:
+		bound = &s.Minimum
+	case s.Type == "integer" || s.Type == "number":
+		bound = &s.Maximum
+	default:
+		return
+	}
+
+	*bound = &n
+}
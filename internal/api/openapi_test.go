@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/api/handler/middleware"
+	"github.com/dmytro-vovk/tro/internal/api/lockout"
+	"github.com/dmytro-vovk/tro/internal/api/openapi"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(logrus.New(), middleware.LoggingConfig{}, nil, nil, lockout.New(lockout.Config{}, nil), nil)
+	r, err := h.Router()
+	require.NoError(t, err)
+
+	engine := r.(*gin.Engine)
+
+	documented := make(map[string]bool)
+	for path, operations := range spec().Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	for _, r := range engine.Routes() {
+		if !strings.HasPrefix(r.Path, "/auth/") && !strings.HasPrefix(r.Path, "/api/") {
+			continue
+		}
+
+		route := r.Method + " " + openapi.Path(r.Path)
+		assert.True(t, documented[route], "%s is not in the spec", route)
+		delete(documented, route)
+	}
+
+	for route := range documented {
+		t.Errorf("%s is in the spec, but not routed", route)
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Contains(t, doc.Components.Schemas, "auth.signUpRequest")
+	assert.Contains(t, doc.Components.Schemas, "middleware.ErrorResponse")
+}
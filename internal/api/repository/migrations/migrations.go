@@ -0,0 +1,289 @@
+// Package migrations applies versioned schema changes embedded into the binary
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// files holds migrations of each driver in a directory named after it,
+// every version has <version>_<name>.up.sql and <version>_<name>.down.sql files
+//
+//go:embed mysql/*.sql postgres/*.sql
+var files embed.FS
+
+const historyTable = "schema_migrations"
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var ErrUnknownVersion = errors.New("unknown migration version")
+
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// Status tells whether the migration is applied, AppliedAt is nil if it's not
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+func New(db *sqlx.DB) (*Migrator, error) {
+	migrations, err := Load(db.DriverName())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Load returns migrations of the driver ordered by version
+func Load(driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, driver)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %q driver: %w", driver, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		m := fileName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(m[1])
+		if version == 0 {
+			return nil, fmt.Errorf("migration %q: versions start at 1", entry.Name())
+		}
+
+		body, err := files.ReadFile(path.Join(driver, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		} else if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %q and %q", version, migration.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			migration.up = string(body)
+		} else {
+			migration.down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the version of the newest migration
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down rolls back the last applied migration
+func (m *Migrator) Down() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.rollback(m.migrations[i])
+		}
+	}
+
+	logrus.Print("No migrations to roll back")
+
+	return nil
+}
+
+// To applies or rolls back migrations so that the schema is at the version,
+// version 0 rolls back everything
+func (m *Migrator) To(version int) error {
+	if version != 0 && m.find(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; ok && migration.Version > version {
+			if err := m.rollback(migration); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+			if err := m.apply(migration); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Status lists all known migrations
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		s := Status{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			s.AppliedAt = &at
+		}
+
+		statuses = append(statuses, s)
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) find(version int) int {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// applied creates the history table if needed and returns applied versions
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    version    INT          NOT NULL PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`, historyTable)
+	if _, err := m.db.Exec(query); err != nil {
+		return nil, fmt.Errorf("error creating migrations history: %w", err)
+	}
+
+	var rows []struct {
+		Version   int       `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	if err := m.db.Select(&rows, fmt.Sprintf("SELECT version, applied_at FROM %s", historyTable)); err != nil {
+		return nil, fmt.Errorf("error reading migrations history: %w", err)
+	}
+
+	applied := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	logrus.Printf("Applying migration %d_%s", migration.Version, migration.Name)
+
+	return m.run(migration, migration.up,
+		fmt.Sprintf("INSERT INTO %s (version, name) VALUES (?, ?)", historyTable),
+		migration.Version, migration.Name,
+	)
+}
+
+func (m *Migrator) rollback(migration Migration) error {
+	logrus.Printf("Rolling back migration %d_%s", migration.Version, migration.Name)
+
+	return m.run(migration, migration.down,
+		fmt.Sprintf("DELETE FROM %s WHERE version=?", historyTable),
+		migration.Version,
+	)
+}
+
+// run executes the migration script and records it in the history in one transaction,
+// MySQL commits DDL statements implicitly, so a failed script may be applied partially there
+func (m *Migrator) run(migration Migration, script, history string, args ...interface{}) error {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() // no-op after commit
+
+	for _, statement := range statements(script) {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	if _, err := tx.Exec(tx.Rebind(history), args...); err != nil {
+		return fmt.Errorf("error recording migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+// statements splits the script by semicolons, they must not appear inside literals
+func statements(script string) []string {
+	var result []string
+	for _, s := range strings.Split(script, ";") {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
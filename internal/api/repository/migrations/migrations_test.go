@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	var versions [][]int
+
+	for _, driver := range []string{"mysql", "postgres"} {
+		migrations, err := Load(driver)
+		require.NoError(t, err, driver)
+
+		var driverVersions []int
+		for i, m := range migrations {
+			assert.Equal(t, i+1, m.Version, "%s migrations must be numbered without gaps", driver)
+			assert.NotEmpty(t, statements(m.up), "%s migration %d up", driver, m.Version)
+			assert.NotEmpty(t, statements(m.down), "%s migration %d down", driver, m.Version)
+
+			driverVersions = append(driverVersions, m.Version)
+		}
+
+		versions = append(versions, driverVersions)
+	}
+
+	assert.Equal(t, versions[0], versions[1], "drivers must have the same migrations")
+
+	_, err := Load("sqlite")
+	assert.Error(t, err)
+}
+
+func TestStatements(t *testing.T) {
+	assert.Equal(t,
+		[]string{"CREATE TABLE a (id INT)", "CREATE INDEX a_id ON a (id)"},
+		statements("CREATE TABLE a (id INT);\n\nCREATE INDEX a_id ON a (id);\n"),
+	)
+}
@@ -0,0 +1,20 @@
+-- the table may exist already, created by schema/database.sql used before migrations,
+-- it's adopted as is and gets the columns added since, users table is adopted by the next migration
+CREATE TABLE IF NOT EXISTS operators (
+    id    INT AUTO_INCREMENT PRIMARY KEY,
+    login VARCHAR(20) NOT NULL,
+    CONSTRAINT operators_login_uindex UNIQUE (login)
+);
+
+-- later versions of schema/database.sql had the column already, MySQL has no ADD COLUMN IF NOT EXISTS
+SET @add_disabled = (
+    SELECT IF(COUNT(*) = 0, 'ALTER TABLE operators ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE', 'DO 0')
+    FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'operators' AND column_name = 'disabled'
+);
+
+PREPARE add_disabled FROM @add_disabled;
+
+EXECUTE add_disabled;
+
+DEALLOCATE PREPARE add_disabled;
@@ -0,0 +1,14 @@
+CREATE TABLE refresh_tokens (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT         NOT NULL,
+    family     VARCHAR(64) NOT NULL,
+    token_hash CHAR(64)    NOT NULL,
+    expires_at TIMESTAMP   NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at    TIMESTAMP   NULL,
+    revoked_at TIMESTAMP   NULL,
+    CONSTRAINT refresh_tokens_token_hash_uindex UNIQUE (token_hash),
+    CONSTRAINT refresh_tokens_users_id_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX refresh_tokens_family_index ON refresh_tokens (family);
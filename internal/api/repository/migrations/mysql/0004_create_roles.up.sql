@@ -0,0 +1,29 @@
+CREATE TABLE roles (
+    id   INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    CONSTRAINT roles_name_uindex UNIQUE (name)
+);
+
+CREATE TABLE role_permissions (
+    role_id    INT         NOT NULL,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role_id, permission),
+    CONSTRAINT role_permissions_roles_id_fk FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_roles (
+    user_id INT NOT NULL,
+    role_id INT NOT NULL,
+    PRIMARY KEY (user_id, role_id),
+    CONSTRAINT user_roles_users_id_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT user_roles_roles_id_fk FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+INSERT INTO roles (name) VALUES ('admin'), ('operator'), ('viewer');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'users:read' FROM roles WHERE name IN ('admin', 'viewer')
+UNION ALL SELECT id, 'users:write' FROM roles WHERE name = 'admin'
+UNION ALL SELECT id, 'operators:read' FROM roles WHERE name IN ('admin', 'operator', 'viewer')
+UNION ALL SELECT id, 'operators:write' FROM roles WHERE name IN ('admin', 'operator')
+UNION ALL SELECT id, 'roles:manage' FROM roles WHERE name = 'admin';
@@ -0,0 +1,16 @@
+CREATE TABLE user_totp (
+    user_id    INT         NOT NULL PRIMARY KEY,
+    secret     VARCHAR(64) NOT NULL,
+    enabled_at TIMESTAMP   NULL,
+    last_step  BIGINT      NOT NULL DEFAULT 0,
+    CONSTRAINT user_totp_users_id_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+    id        INT AUTO_INCREMENT PRIMARY KEY,
+    user_id   INT       NOT NULL,
+    code_hash CHAR(64)  NOT NULL,
+    used_at   TIMESTAMP NULL,
+    CONSTRAINT recovery_codes_user_code_uindex UNIQUE (user_id, code_hash),
+    CONSTRAINT recovery_codes_users_id_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+DROP TABLE user_tokens;
+
+DROP INDEX users_email_uindex ON users;
+
+ALTER TABLE users DROP COLUMN email, DROP COLUMN email_verified_at;
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN email VARCHAR(255) NULL, ADD COLUMN email_verified_at TIMESTAMP NULL;
+
+CREATE UNIQUE INDEX users_email_uindex ON users (email);
+
+CREATE TABLE user_tokens (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT         NOT NULL,
+    purpose    VARCHAR(20) NOT NULL,
+    token_hash CHAR(64)    NOT NULL,
+    expires_at TIMESTAMP   NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at    TIMESTAMP   NULL,
+    CONSTRAINT user_tokens_token_hash_uindex UNIQUE (token_hash),
+    CONSTRAINT user_tokens_users_id_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+CREATE TABLE api_keys (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT          NOT NULL,
+    name         VARCHAR(255) NOT NULL,
+    prefix       VARCHAR(16)  NOT NULL,
+    key_hash     CHAR(64)     NOT NULL,
+    scopes       VARCHAR(500) NOT NULL,
+    created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP    NULL,
+    CONSTRAINT api_keys_key_hash_uindex UNIQUE (key_hash),
+    CONSTRAINT api_keys_users_id_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
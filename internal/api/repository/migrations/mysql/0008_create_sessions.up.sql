@@ -0,0 +1,19 @@
+CREATE TABLE sessions (
+    family       VARCHAR(64)  NOT NULL PRIMARY KEY,
+    user_id      INT          NOT NULL,
+    device       VARCHAR(255) NOT NULL,
+    user_agent   VARCHAR(500) NOT NULL,
+    ip           VARCHAR(45)  NOT NULL,
+    created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT sessions_users_id_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX sessions_user_id_index ON sessions (user_id);
+
+-- sessions started before this migration, their devices aren't known
+INSERT INTO sessions (family, user_id, device, user_agent, ip, created_at, last_seen_at)
+SELECT family, user_id, '', '', '', MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+WHERE revoked_at IS NULL
+GROUP BY family, user_id;
@@ -0,0 +1,20 @@
+-- no foreign keys, events outlive users they mention
+CREATE TABLE audit_log (
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at      TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    actor_id        INT          NULL,
+    impersonator_id INT          NULL,
+    action          VARCHAR(50)  NOT NULL,
+    target          VARCHAR(100) NOT NULL,
+    before_state    TEXT         NULL,
+    after_state     TEXT         NULL,
+    ip              VARCHAR(45)  NOT NULL,
+    request_id      VARCHAR(64)  NOT NULL
+);
+
+CREATE INDEX audit_log_created_at_index ON audit_log (created_at);
+CREATE INDEX audit_log_actor_id_index ON audit_log (actor_id);
+CREATE INDEX audit_log_target_index ON audit_log (target);
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'audit:read' FROM roles WHERE name = 'admin';
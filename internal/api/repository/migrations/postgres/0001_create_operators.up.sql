@@ -0,0 +1,6 @@
+CREATE TABLE operators (
+    id       SERIAL PRIMARY KEY,
+    login    VARCHAR(20) NOT NULL,
+    disabled BOOLEAN     NOT NULL DEFAULT FALSE,
+    CONSTRAINT operators_login_uindex UNIQUE (login)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE refresh_tokens (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family     VARCHAR(64) NOT NULL,
+    token_hash CHAR(64)    NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    used_at    TIMESTAMPTZ NULL,
+    revoked_at TIMESTAMPTZ NULL,
+    CONSTRAINT refresh_tokens_token_hash_uindex UNIQUE (token_hash)
+);
+
+CREATE INDEX refresh_tokens_family_index ON refresh_tokens (family);
@@ -0,0 +1,14 @@
+CREATE TABLE user_totp (
+    user_id    INT         NOT NULL PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret     VARCHAR(64) NOT NULL,
+    enabled_at TIMESTAMPTZ NULL,
+    last_step  BIGINT      NOT NULL DEFAULT 0
+);
+
+CREATE TABLE recovery_codes (
+    id        SERIAL PRIMARY KEY,
+    user_id   INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash CHAR(64)    NOT NULL,
+    used_at   TIMESTAMPTZ NULL,
+    CONSTRAINT recovery_codes_user_code_uindex UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN email VARCHAR(255) NULL, ADD COLUMN email_verified_at TIMESTAMPTZ NULL;
+
+CREATE UNIQUE INDEX users_email_uindex ON users (email);
+
+CREATE TABLE user_tokens (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose    VARCHAR(20) NOT NULL,
+    token_hash CHAR(64)    NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    used_at    TIMESTAMPTZ NULL,
+    CONSTRAINT user_tokens_token_hash_uindex UNIQUE (token_hash)
+);
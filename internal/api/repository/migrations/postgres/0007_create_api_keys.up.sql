@@ -0,0 +1,11 @@
+CREATE TABLE api_keys (
+    id           SERIAL PRIMARY KEY,
+    user_id      INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         VARCHAR(255) NOT NULL,
+    prefix       VARCHAR(16)  NOT NULL,
+    key_hash     CHAR(64)     NOT NULL,
+    scopes       VARCHAR(500) NOT NULL,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ  NULL,
+    CONSTRAINT api_keys_key_hash_uindex UNIQUE (key_hash)
+);
@@ -0,0 +1,18 @@
+CREATE TABLE sessions (
+    family       VARCHAR(64)  NOT NULL PRIMARY KEY,
+    user_id      INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    device       VARCHAR(255) NOT NULL,
+    user_agent   VARCHAR(500) NOT NULL,
+    ip           VARCHAR(45)  NOT NULL,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    last_seen_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX sessions_user_id_index ON sessions (user_id);
+
+-- sessions started before this migration, their devices aren't known
+INSERT INTO sessions (family, user_id, device, user_agent, ip, created_at, last_seen_at)
+SELECT family, user_id, '', '', '', MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+WHERE revoked_at IS NULL
+GROUP BY family, user_id;
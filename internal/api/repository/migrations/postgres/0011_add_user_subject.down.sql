@@ -0,0 +1,3 @@
+DROP INDEX users_subject_uindex;
+
+ALTER TABLE users DROP COLUMN subject;
@@ -0,0 +1,4 @@
+-- subject of the external identity provider, users are matched by it rather than by username
+ALTER TABLE users ADD COLUMN subject VARCHAR(255) NULL;
+
+CREATE UNIQUE INDEX users_subject_uindex ON users (subject);
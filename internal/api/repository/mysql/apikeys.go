@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+const (
+	apiKeysTable = "api_keys"
+
+	apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at"
+)
+
+func (s *storage) CreateAPIKey(key model.APIKey) (int, error) {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, name, prefix, key_hash, scopes) VALUES (?, ?, ?, ?, ?)", apiKeysTable)
+	res, err := s.db.Exec(query, key.UserID, key.Name, key.Prefix, key.Hash, key.Scopes)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *storage) GetAPIKeys(userID int) ([]model.APIKey, error) {
+	keys := []model.APIKey{}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id=? ORDER BY id", apiKeyColumns, apiKeysTable)
+	if err := s.db.Select(&keys, query, userID); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (s *storage) GetAPIKeyByHash(hash string) (model.APIKey, error) {
+	var key model.APIKey
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE key_hash=?", apiKeyColumns, apiKeysTable)
+	err := s.db.Get(&key, query, hash)
+
+	return key, sqlutil.NotFound(err, model.ErrAPIKeyNotFound)
+}
+
+func (s *storage) TouchAPIKey(id int, usedAt time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET last_used_at=? WHERE id=?", apiKeysTable)
+	_, err := s.db.Exec(query, usedAt, id)
+
+	return err
+}
+
+func (s *storage) DeleteAPIKey(userID, id int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id=? AND user_id=?", apiKeysTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, id, userID)), model.ErrAPIKeyNotFound)
+}
@@ -0,0 +1,93 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+const (
+	auditTable = "audit_log"
+
+	// zero ids are stored as NULL
+	auditColumns = "id, created_at, COALESCE(actor_id, 0) AS actor_id, COALESCE(impersonator_id, 0) AS impersonator_id, " +
+		"action, target, before_state, after_state, ip, request_id"
+)
+
+func (s *storage) CreateAuditEvent(event model.AuditEvent) (int, error) {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (created_at, actor_id, impersonator_id, action, target, before_state, after_state, ip, request_id) "+
+			"VALUES (?, NULLIF(?, 0), NULLIF(?, 0), ?, ?, ?, ?, ?, ?)",
+		auditTable,
+	)
+	res, err := s.db.Exec(
+		query,
+		event.Time, event.ActorID, event.ImpersonatorID, event.Action, event.Target, event.Before, event.After, event.IP, event.RequestID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *storage) GetAuditEvents(filter model.AuditFilter) ([]model.AuditEvent, int, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	if filter.ActorID != 0 {
+		where = append(where, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+
+	if strings.HasSuffix(filter.Action, ".") {
+		where = append(where, "action LIKE ?")
+		args = append(args, sqlutil.LikePrefix(filter.Action))
+	} else if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, filter.Action)
+	}
+
+	if filter.Target != "" {
+		where = append(where, "target = ?")
+		args = append(args, filter.Target)
+	}
+
+	if !filter.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.To)
+	}
+
+	conditions := ""
+	if len(where) > 0 {
+		conditions = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.Get(&total, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", auditTable, conditions), args...); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY id DESC LIMIT ? OFFSET ?", auditColumns, auditTable, conditions)
+
+	events := []model.AuditEvent{}
+	if err := s.db.Select(&events, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
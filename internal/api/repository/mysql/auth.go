@@ -3,13 +3,17 @@ package mysql
 import (
 	"fmt"
 	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
 	"github.com/sirupsen/logrus"
+	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
+const usersTable = "users"
+
 type Config struct {
 	Username string `mapstructure:"MYSQL_USERNAME"`
 	Password string `mapstructure:"MYSQL_PASSWORD"`
@@ -20,7 +24,7 @@ type Config struct {
 
 func (c Config) DSN() string {
 	return fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s",
+		"%s:%s@tcp(%s:%s)/%s?parseTime=true&clientFoundRows=true",
 		c.Username,
 		c.Password,
 		c.Host,
@@ -57,10 +61,62 @@ func (s *storage) Close() error { return s.db.Close() }
 
 func (s *storage) DriverName() string { return s.db.DriverName() }
 
+func (s *storage) DB() *sqlx.DB { return s.db }
+
 func (s *storage) CreateUser(user model.User) (int, error) {
-	return 0, nil
+	query := fmt.Sprintf("INSERT INTO %s (name, username, password_hash, email, subject) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''))", usersTable)
+	res, err := s.db.Exec(query, user.Name, user.Username, user.Password, user.Email, user.Subject)
+	if err != nil {
+		if isDuplicate(err) {
+			return 0, duplicateUser(err)
+		}
+
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *storage) GetUserByUsername(username string) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE username=?", userColumns, usersTable)
+	err := s.db.Get(&user, query, username)
+
+	return user, sqlutil.NotFound(err, model.ErrUserNotFound)
+}
+
+func (s *storage) GetUserByEmail(email string) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE email=?", userColumns, usersTable)
+	err := s.db.Get(&user, query, email)
+
+	return user, sqlutil.NotFound(err, model.ErrUserNotFound)
+}
+
+func (s *storage) GetUserBySubject(subject string) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE subject=?", userColumns, usersTable)
+	err := s.db.Get(&user, query, subject)
+
+	return user, sqlutil.NotFound(err, model.ErrUserNotFound)
+}
+
+func (s *storage) SetEmailVerified(userID int) error {
+	query := fmt.Sprintf("UPDATE %s SET email_verified_at=? WHERE id=?", usersTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, time.Now(), userID)), model.ErrUserNotFound)
 }
 
-func (s *storage) GetUser(username, password string) (model.User, error) {
-	return model.User{}, nil
+// duplicateUser tells which unique field of the user is taken, drivers put the index name into the message
+func duplicateUser(err error) error {
+	if strings.Contains(err.Error(), "users_email_uindex") {
+		return model.ErrEmailTaken
+	}
+
+	return model.ErrUsernameTaken
 }
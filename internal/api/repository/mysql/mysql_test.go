@@ -0,0 +1,44 @@
+package mysql_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/api/repository/mysql"
+	"github.com/dmytro-vovk/tro/internal/api/repository/repositorytest"
+)
+
+// TestStorage runs against the database from MYSQL_* environment variables with migrations applied
+func TestStorage(t *testing.T) {
+	config := mysql.Config{
+		Username: os.Getenv("MYSQL_USERNAME"),
+		Password: os.Getenv("MYSQL_PASSWORD"),
+		Host:     os.Getenv("MYSQL_HOST"),
+		Port:     os.Getenv("MYSQL_PORT"),
+		Database: os.Getenv("MYSQL_DATABASE"),
+	}
+	if config.Host == "" {
+		t.Skip("MYSQL_HOST is not set")
+	}
+
+	// mysql.New waits for the database forever, don't let it hang the test
+	db, err := sql.Open("mysql", config.DSN())
+	if err == nil {
+		err = db.Ping()
+		db.Close()
+	}
+
+	if err != nil {
+		t.Skipf("database is not available: %s", err)
+	}
+
+	repo, err := mysql.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer repo.Close()
+
+	repositorytest.Run(t, repo)
+}
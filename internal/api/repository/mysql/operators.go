@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	operatorsTable = "operators"
+
+	errDuplicateEntry = 1062 // ER_DUP_ENTRY
+)
+
+func (s *storage) GetOperators() ([]model.Operator, error) {
+	operators := []model.Operator{}
+	query := fmt.Sprintf("SELECT id, login, disabled FROM %s ORDER BY id", operatorsTable)
+	if err := s.db.Select(&operators, query); err != nil {
+		return nil, err
+	}
+
+	return operators, nil
+}
+
+func (s *storage) GetOperator(id int) (model.Operator, error) {
+	var operator model.Operator
+	query := fmt.Sprintf("SELECT id, login, disabled FROM %s WHERE id=?", operatorsTable)
+	err := s.db.Get(&operator, query, id)
+
+	return operator, sqlutil.NotFound(err, model.ErrOperatorNotFound)
+}
+
+func (s *storage) CreateOperator(login string) (int, error) {
+	res, err := s.db.Exec(fmt.Sprintf("INSERT INTO %s (login) VALUES (?)", operatorsTable), login)
+	if err != nil {
+		if isDuplicate(err) {
+			return 0, model.ErrOperatorLoginTaken
+		}
+
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *storage) RenameOperator(id int, login string) error {
+	query := fmt.Sprintf("UPDATE %s SET login=? WHERE id=?", operatorsTable)
+	err := sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, login, id)), model.ErrOperatorNotFound)
+	if isDuplicate(err) {
+		return model.ErrOperatorLoginTaken
+	}
+
+	return err
+}
+
+func (s *storage) DisableOperator(id int) error {
+	query := fmt.Sprintf("UPDATE %s SET disabled=TRUE WHERE id=?", operatorsTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, id)), model.ErrOperatorNotFound)
+}
+
+func (s *storage) DeleteOperator(id int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id=?", operatorsTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, id)), model.ErrOperatorNotFound)
+}
+
+// isDuplicate reports whether the error is unique key violation
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry
+}
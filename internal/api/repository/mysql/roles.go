@@ -0,0 +1,109 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	rolesTable           = "roles"
+	rolePermissionsTable = "role_permissions"
+	userRolesTable       = "user_roles"
+)
+
+type rolePermission struct {
+	ID         int               `db:"id"`
+	Name       string            `db:"name"`
+	Permission *model.Permission `db:"permission"`
+}
+
+func (s *storage) GetRoles() ([]model.Role, error) {
+	query := fmt.Sprintf(
+		"SELECT r.id, r.name, p.permission FROM %s r LEFT JOIN %s p ON p.role_id=r.id ORDER BY r.id, p.permission",
+		rolesTable, rolePermissionsTable,
+	)
+
+	return s.selectRoles(query)
+}
+
+func (s *storage) GetUserRoles(userID int) ([]model.Role, error) {
+	query := fmt.Sprintf(
+		"SELECT r.id, r.name, p.permission FROM %s ur JOIN %s r ON r.id=ur.role_id LEFT JOIN %s p ON p.role_id=r.id WHERE ur.user_id=? ORDER BY r.id, p.permission",
+		userRolesTable, rolesTable, rolePermissionsTable,
+	)
+
+	return s.selectRoles(query, userID)
+}
+
+func (s *storage) SetUserRoles(userID int, roles []string) error {
+	if _, err := s.GetUserByID(userID); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() // no-op after commit
+
+	var ids []int
+	if len(roles) > 0 {
+		query, args, err := sqlx.In(fmt.Sprintf("SELECT id FROM %s WHERE name IN (?)", rolesTable), roles)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Select(&ids, query, args...); err != nil {
+			return err
+		}
+
+		if len(ids) != len(unique(roles)) {
+			return model.ErrRoleNotFound
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id=?", userRolesTable), userID); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (user_id, role_id) VALUES (?, ?)", userRolesTable), userID, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *storage) selectRoles(query string, args ...interface{}) ([]model.Role, error) {
+	var rows []rolePermission
+	if err := s.db.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	roles := []model.Role{}
+	for _, row := range rows {
+		if len(roles) == 0 || roles[len(roles)-1].ID != row.ID {
+			roles = append(roles, model.Role{ID: row.ID, Name: row.Name, Permissions: []model.Permission{}})
+		}
+
+		if row.Permission != nil {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, *row.Permission)
+		}
+	}
+
+	return roles, nil
+}
+
+func unique(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+
+	return set
+}
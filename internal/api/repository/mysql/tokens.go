@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+const (
+	refreshTokensTable = "refresh_tokens"
+
+	refreshTokenColumns = "id, user_id, family, token_hash, expires_at, created_at, used_at, revoked_at"
+)
+
+func (s *storage) CreateRefreshToken(token model.RefreshToken) error {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, family, token_hash, expires_at) VALUES (?, ?, ?, ?)", refreshTokensTable)
+	_, err := s.db.Exec(query, token.UserID, token.Family, token.Hash, token.ExpiresAt)
+
+	return err
+}
+
+func (s *storage) GetRefreshToken(hash string) (model.RefreshToken, error) {
+	var token model.RefreshToken
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE token_hash=?", refreshTokenColumns, refreshTokensTable)
+	err := s.db.Get(&token, query, hash)
+
+	return token, sqlutil.NotFound(err, model.ErrTokenNotFound)
+}
+
+func (s *storage) UseRefreshToken(hash string) error {
+	query := fmt.Sprintf("UPDATE %s SET used_at=? WHERE token_hash=? AND used_at IS NULL AND revoked_at IS NULL", refreshTokensTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, time.Now(), hash)), model.ErrTokenNotFound)
+}
+
+func (s *storage) RevokeTokenFamily(family string) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked_at=? WHERE family=? AND revoked_at IS NULL", refreshTokensTable)
+	_, err := s.db.Exec(query, time.Now(), family)
+
+	return err
+}
+
+func (s *storage) RevokeUserTokens(userID int) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked_at=? WHERE user_id=? AND revoked_at IS NULL", refreshTokensTable)
+	_, err := s.db.Exec(query, time.Now(), userID)
+
+	return err
+}
+
+func (s *storage) TokenFamilyActive(family string) (bool, error) {
+	var n int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE family=? AND revoked_at IS NULL AND expires_at > ?", refreshTokensTable)
+	err := s.db.Get(&n, query, family, time.Now())
+
+	return n > 0, err
+}
@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+// email is NULL if not set, so the unique index ignores it
+const userColumns = "id, name, username, password_hash, COALESCE(email, '') AS email, email_verified_at, COALESCE(subject, '') AS subject, created_at"
+
+func (s *storage) GetUsers(filter model.UserFilter) ([]model.User, int, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	if filter.Name != "" {
+		where = append(where, "name LIKE ?")
+		args = append(args, sqlutil.Like(filter.Name))
+	}
+
+	if filter.Username != "" {
+		where = append(where, "username LIKE ?")
+		args = append(args, sqlutil.Like(filter.Username))
+	}
+
+	conditions := ""
+	if len(where) > 0 {
+		conditions = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.Get(&total, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", usersTable, conditions), args...); err != nil {
+		return nil, 0, err
+	}
+
+	field, desc := filter.Order()
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s %s LIMIT ? OFFSET ?", userColumns, usersTable, conditions, field, order)
+
+	users := []model.User{}
+	if err := s.db.Select(&users, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (s *storage) GetUserByID(id int) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id=?", userColumns, usersTable)
+	err := s.db.Get(&user, query, id)
+
+	return user, sqlutil.NotFound(err, model.ErrUserNotFound)
+}
+
+func (s *storage) UpdateUser(id int, update model.UserUpdate) error {
+	var (
+		set  []string
+		args []interface{}
+	)
+
+	for _, field := range []struct {
+		column string
+		value  *string
+	}{
+		{"name", update.Name},
+		{"username", update.Username},
+		{"password_hash", update.Password},
+	} {
+		if field.value != nil {
+			set = append(set, field.column+"=?")
+			args = append(args, *field.value)
+		}
+	}
+
+	if update.Email != nil {
+		set = append(set, "email=NULLIF(?, '')", "email_verified_at=NULL")
+		args = append(args, *update.Email)
+	}
+
+	if len(set) == 0 {
+		_, err := s.GetUserByID(id)
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=?", usersTable, strings.Join(set, ", "))
+
+	err := sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, append(args, id)...)), model.ErrUserNotFound)
+	if isDuplicate(err) {
+		return duplicateUser(err)
+	}
+
+	return err
+}
+
+func (s *storage) DeleteUser(id int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id=?", usersTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, id)), model.ErrUserNotFound)
+}
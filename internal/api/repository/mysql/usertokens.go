@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+const userTokensTable = "user_tokens"
+
+func (s *storage) CreateUserToken(token model.UserToken) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() // no-op after commit
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id=? AND purpose=? AND used_at IS NULL", userTokensTable)
+	if _, err := tx.Exec(query, token.UserID, token.Purpose); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (user_id, purpose, token_hash, expires_at) VALUES (?, ?, ?, ?)", userTokensTable)
+	if _, err := tx.Exec(query, token.UserID, token.Purpose, token.Hash, token.ExpiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *storage) UseUserToken(purpose, hash string) (int, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback() // no-op after commit
+
+	var userID int
+	now := time.Now()
+	query := fmt.Sprintf(
+		"SELECT user_id FROM %s WHERE token_hash=? AND purpose=? AND used_at IS NULL AND expires_at>?",
+		userTokensTable,
+	)
+	if err := tx.Get(&userID, query, hash, purpose, now); errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrInvalidToken
+	} else if err != nil {
+		return 0, err
+	}
+
+	// the condition on used_at makes concurrent uses of the token fail
+	query = fmt.Sprintf("UPDATE %s SET used_at=? WHERE token_hash=? AND used_at IS NULL", userTokensTable)
+	if err := sqlutil.NotFound(sqlutil.Affected(tx.Exec(query, now, hash)), model.ErrInvalidToken); err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
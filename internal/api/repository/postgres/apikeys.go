@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+const (
+	apiKeysTable = "api_keys"
+
+	apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at"
+)
+
+func (s *storage) CreateAPIKey(key model.APIKey) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, name, prefix, key_hash, scopes) VALUES ($1, $2, $3, $4, $5) RETURNING id", apiKeysTable)
+	if err := s.db.QueryRow(query, key.UserID, key.Name, key.Prefix, key.Hash, key.Scopes).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *storage) GetAPIKeys(userID int) ([]model.APIKey, error) {
+	keys := []model.APIKey{}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id=$1 ORDER BY id", apiKeyColumns, apiKeysTable)
+	if err := s.db.Select(&keys, query, userID); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (s *storage) GetAPIKeyByHash(hash string) (model.APIKey, error) {
+	var key model.APIKey
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE key_hash=$1", apiKeyColumns, apiKeysTable)
+	err := s.db.Get(&key, query, hash)
+
+	return key, sqlutil.NotFound(err, model.ErrAPIKeyNotFound)
+}
+
+func (s *storage) TouchAPIKey(id int, usedAt time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET last_used_at=$1 WHERE id=$2", apiKeysTable)
+	_, err := s.db.Exec(query, usedAt, id)
+
+	return err
+}
+
+func (s *storage) DeleteAPIKey(userID, id int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id=$1 AND user_id=$2", apiKeysTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, id, userID)), model.ErrAPIKeyNotFound)
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+const (
+	auditTable = "audit_log"
+
+	// zero ids are stored as NULL
+	auditColumns = "id, created_at, COALESCE(actor_id, 0) AS actor_id, COALESCE(impersonator_id, 0) AS impersonator_id, " +
+		"action, target, before_state, after_state, ip, request_id"
+)
+
+func (s *storage) CreateAuditEvent(event model.AuditEvent) (int, error) {
+	var id int
+	query := fmt.Sprintf(
+		"INSERT INTO %s (created_at, actor_id, impersonator_id, action, target, before_state, after_state, ip, request_id) "+
+			"VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7, $8, $9) RETURNING id",
+		auditTable,
+	)
+	if err := s.db.QueryRow(
+		query,
+		event.Time, event.ActorID, event.ImpersonatorID, event.Action, event.Target, event.Before, event.After, event.IP, event.RequestID,
+	).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *storage) GetAuditEvents(filter model.AuditFilter) ([]model.AuditEvent, int, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	if filter.ActorID != 0 {
+		args = append(args, filter.ActorID)
+		where = append(where, fmt.Sprintf("actor_id = $%d", len(args)))
+	}
+
+	if strings.HasSuffix(filter.Action, ".") {
+		args = append(args, sqlutil.LikePrefix(filter.Action))
+		where = append(where, fmt.Sprintf("action LIKE $%d", len(args)))
+	} else if filter.Action != "" {
+		args = append(args, filter.Action)
+		where = append(where, fmt.Sprintf("action = $%d", len(args)))
+	}
+
+	if filter.Target != "" {
+		args = append(args, filter.Target)
+		where = append(where, fmt.Sprintf("target = $%d", len(args)))
+	}
+
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		where = append(where, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		where = append(where, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	conditions := ""
+	if len(where) > 0 {
+		conditions = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.Get(&total, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", auditTable, conditions), args...); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s%s ORDER BY id DESC LIMIT $%d OFFSET $%d",
+		auditColumns, auditTable, conditions, len(args)-1, len(args),
+	)
+
+	events := []model.AuditEvent{}
+	if err := s.db.Select(&events, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
@@ -2,8 +2,11 @@ package postgres
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -51,21 +54,58 @@ func (s *storage) Close() error { return s.db.Close() }
 
 func (s *storage) DriverName() string { return s.db.DriverName() }
 
+func (s *storage) DB() *sqlx.DB { return s.db }
+
 func (s *storage) CreateUser(user model.User) (int, error) {
 	var id int
-	query := fmt.Sprintf("INSERT INTO %s (name, username, password_hash) values ($1, $2, $3) RETURNING id", usersTable)
-	row := s.db.QueryRow(query, user.Name, user.Username, user.Password)
+	query := fmt.Sprintf("INSERT INTO %s (name, username, password_hash, email, subject) values ($1, $2, $3, NULLIF($4, ''), NULLIF($5, '')) RETURNING id", usersTable)
+	row := s.db.QueryRow(query, user.Name, user.Username, user.Password, user.Email, user.Subject)
 	if err := row.Scan(&id); err != nil {
+		if isDuplicate(err) {
+			return 0, duplicateUser(err)
+		}
+
 		return 0, err
 	}
 
 	return id, nil
 }
 
-func (s *storage) GetUser(username, password string) (model.User, error) {
+func (s *storage) GetUserByUsername(username string) (model.User, error) {
 	var user model.User
-	query := fmt.Sprintf("SELECT id FROM %s WHERE username=$1 AND password_hash=$2", usersTable)
-	err := s.db.Get(&user, query, username, password)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE username=$1", userColumns, usersTable)
+	err := s.db.Get(&user, query, username)
+
+	return user, sqlutil.NotFound(err, model.ErrUserNotFound)
+}
+
+func (s *storage) GetUserByEmail(email string) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE email=$1", userColumns, usersTable)
+	err := s.db.Get(&user, query, email)
+
+	return user, sqlutil.NotFound(err, model.ErrUserNotFound)
+}
+
+func (s *storage) GetUserBySubject(subject string) (model.User, error) {
+	var user model.User
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE subject=$1", userColumns, usersTable)
+	err := s.db.Get(&user, query, subject)
+
+	return user, sqlutil.NotFound(err, model.ErrUserNotFound)
+}
+
+func (s *storage) SetEmailVerified(userID int) error {
+	query := fmt.Sprintf("UPDATE %s SET email_verified_at=$1 WHERE id=$2", usersTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, time.Now(), userID)), model.ErrUserNotFound)
+}
+
+// duplicateUser tells which unique field of the user is taken, drivers put the index name into the message
+func duplicateUser(err error) error {
+	if strings.Contains(err.Error(), "users_email_uindex") {
+		return model.ErrEmailTaken
+	}
 
-	return user, err
+	return model.ErrUsernameTaken
 }
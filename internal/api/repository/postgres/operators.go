@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+const (
+	operatorsTable = "operators"
+
+	uniqueViolation = "23505"
+)
+
+func (s *storage) GetOperators() ([]model.Operator, error) {
+	operators := []model.Operator{}
+	query := fmt.Sprintf("SELECT id, login, disabled FROM %s ORDER BY id", operatorsTable)
+	if err := s.db.Select(&operators, query); err != nil {
+		return nil, err
+	}
+
+	return operators, nil
+}
+
+func (s *storage) GetOperator(id int) (model.Operator, error) {
+	var operator model.Operator
+	query := fmt.Sprintf("SELECT id, login, disabled FROM %s WHERE id=$1", operatorsTable)
+	err := s.db.Get(&operator, query, id)
+
+	return operator, sqlutil.NotFound(err, model.ErrOperatorNotFound)
+}
+
+func (s *storage) CreateOperator(login string) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (login) VALUES ($1) RETURNING id", operatorsTable)
+	if err := s.db.QueryRow(query, login).Scan(&id); err != nil {
+		if isDuplicate(err) {
+			return 0, model.ErrOperatorLoginTaken
+		}
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *storage) RenameOperator(id int, login string) error {
+	query := fmt.Sprintf("UPDATE %s SET login=$1 WHERE id=$2", operatorsTable)
+	err := sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, login, id)), model.ErrOperatorNotFound)
+	if isDuplicate(err) {
+		return model.ErrOperatorLoginTaken
+	}
+
+	return err
+}
+
+func (s *storage) DisableOperator(id int) error {
+	query := fmt.Sprintf("UPDATE %s SET disabled=TRUE WHERE id=$1", operatorsTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, id)), model.ErrOperatorNotFound)
+}
+
+func (s *storage) DeleteOperator(id int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id=$1", operatorsTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, id)), model.ErrOperatorNotFound)
+}
+
+// isDuplicate reports whether the error is unique key violation,
+// SQLSTATE is checked so it doesn't depend on the driver
+func isDuplicate(err error) bool {
+	var stateErr interface{ SQLState() string }
+
+	return errors.As(err, &stateErr) && stateErr.SQLState() == uniqueViolation
+}
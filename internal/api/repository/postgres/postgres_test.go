@@ -0,0 +1,33 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/api/repository/postgres"
+	"github.com/dmytro-vovk/tro/internal/api/repository/repositorytest"
+)
+
+// TestStorage runs against the database from POSTGRESQL_* environment variables with migrations applied
+func TestStorage(t *testing.T) {
+	config := postgres.Config{
+		Username: os.Getenv("POSTGRESQL_USERNAME"),
+		Password: os.Getenv("POSTGRESQL_PASSWORD"),
+		Host:     os.Getenv("POSTGRESQL_HOST"),
+		Port:     os.Getenv("POSTGRESQL_PORT"),
+		Database: os.Getenv("POSTGRESQL_DATABASE"),
+		SSLMode:  os.Getenv("POSTGRESQL_SSLMODE"),
+	}
+	if config.Host == "" {
+		t.Skip("POSTGRESQL_HOST is not set")
+	}
+
+	repo, err := postgres.New(config)
+	if err != nil {
+		t.Skipf("database is not available: %s", err)
+	}
+
+	defer repo.Close()
+
+	repositorytest.Run(t, repo)
+}
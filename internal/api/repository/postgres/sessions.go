@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+const (
+	sessionsTable = "sessions"
+
+	sessionColumns = "family, user_id, device, user_agent, ip, created_at, last_seen_at"
+
+	sessionTouchEvery = time.Minute
+)
+
+func (s *storage) CreateSession(session model.Session) error {
+	query := fmt.Sprintf("INSERT INTO %s (family, user_id, device, user_agent, ip) VALUES ($1, $2, $3, $4, $5)", sessionsTable)
+	_, err := s.db.Exec(query, session.ID, session.UserID, session.Name, session.UserAgent, session.IP)
+
+	return err
+}
+
+func (s *storage) GetSessions(userID int) ([]model.Session, error) {
+	sessions := []model.Session{}
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s s WHERE user_id=$1 AND EXISTS "+
+			"(SELECT 1 FROM %s t WHERE t.family=s.family AND t.revoked_at IS NULL AND t.expires_at > $2) "+
+			"ORDER BY last_seen_at DESC",
+		sessionColumns, sessionsTable, refreshTokensTable,
+	)
+	if err := s.db.Select(&sessions, query, userID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (s *storage) TouchSession(family, ip string, seenAt time.Time) error {
+	query := fmt.Sprintf(
+		"UPDATE %s SET last_seen_at=$1, ip=COALESCE(NULLIF($2, ''), ip) WHERE family=$3 AND (last_seen_at < $4 OR $5 NOT IN ('', ip))",
+		sessionsTable,
+	)
+	_, err := s.db.Exec(query, seenAt, ip, family, seenAt.Add(-sessionTouchEvery), ip)
+
+	return err
+}
+
+func (s *storage) RevokeSession(userID int, family string) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked_at=$1 WHERE family=$2 AND user_id=$3 AND revoked_at IS NULL", refreshTokensTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, time.Now(), family, userID)), model.ErrSessionNotFound)
+}
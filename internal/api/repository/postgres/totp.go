@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository/sqlutil"
+)
+
+const (
+	totpTable          = "user_totp"
+	recoveryCodesTable = "recovery_codes"
+)
+
+func (s *storage) GetTOTP(userID int) (model.TOTP, error) {
+	var totp model.TOTP
+	query := fmt.Sprintf("SELECT user_id, secret, enabled_at, last_step FROM %s WHERE user_id=$1", totpTable)
+	err := s.db.Get(&totp, query, userID)
+
+	return totp, sqlutil.NotFound(err, model.ErrTOTPNotEnrolled)
+}
+
+func (s *storage) SaveTOTP(userID int, secret string) error {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (user_id, secret) VALUES ($1, $2) ON CONFLICT (user_id) DO UPDATE SET secret=EXCLUDED.secret, enabled_at=NULL, last_step=0",
+		totpTable,
+	)
+	_, err := s.db.Exec(query, userID, secret)
+
+	return err
+}
+
+func (s *storage) EnableTOTP(userID int, recoveryCodeHashes []string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() // no-op after commit
+
+	query := fmt.Sprintf("UPDATE %s SET enabled_at=$1 WHERE user_id=$2", totpTable)
+	if err := sqlutil.NotFound(sqlutil.Affected(tx.Exec(query, time.Now(), userID)), model.ErrTOTPNotEnrolled); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id=$1", recoveryCodesTable), userID); err != nil {
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		query := fmt.Sprintf("INSERT INTO %s (user_id, code_hash) VALUES ($1, $2)", recoveryCodesTable)
+		if _, err := tx.Exec(query, userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *storage) DeleteTOTP(userID int) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback() // no-op after commit
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id=$1", recoveryCodesTable), userID); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id=$1", totpTable)
+	if err := sqlutil.NotFound(sqlutil.Affected(tx.Exec(query, userID)), model.ErrTOTPNotEnrolled); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *storage) UseTOTPStep(userID int, step int64) error {
+	query := fmt.Sprintf("UPDATE %s SET last_step=$1 WHERE user_id=$2 AND last_step<$3", totpTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, step, userID, step)), model.ErrInvalidCode)
+}
+
+func (s *storage) UseRecoveryCode(userID int, hash string) error {
+	query := fmt.Sprintf("UPDATE %s SET used_at=$1 WHERE user_id=$2 AND code_hash=$3 AND used_at IS NULL", recoveryCodesTable)
+
+	return sqlutil.NotFound(sqlutil.Affected(s.db.Exec(query, time.Now(), userID, hash)), model.ErrInvalidCode)
+}
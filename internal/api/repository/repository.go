@@ -2,6 +2,9 @@ package repository
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 	"github.com/spf13/viper"
 
 	"github.com/dmytro-vovk/tro/internal/api/model"
@@ -12,16 +15,117 @@ import (
 type Storage interface {
 	Close() error
 	DriverName() string
+	DB() *sqlx.DB
 }
 
 type Authorization interface {
 	CreateUser(user model.User) (int, error)
-	GetUser(username, password string) (model.User, error)
+	GetUserByUsername(username string) (model.User, error)
+	GetUserByEmail(email string) (model.User, error)
+	// GetUserBySubject finds the user provisioned from the external identity provider
+	GetUserBySubject(subject string) (model.User, error)
+	SetEmailVerified(userID int) error
+}
+
+type Users interface {
+	GetUsers(filter model.UserFilter) ([]model.User, int, error)
+	GetUserByID(id int) (model.User, error)
+	UpdateUser(id int, update model.UserUpdate) error
+	DeleteUser(id int) error
+}
+
+type Operators interface {
+	GetOperators() ([]model.Operator, error)
+	GetOperator(id int) (model.Operator, error)
+	CreateOperator(login string) (int, error)
+	RenameOperator(id int, login string) error
+	DisableOperator(id int) error
+	DeleteOperator(id int) error
+}
+
+type Tokens interface {
+	CreateRefreshToken(token model.RefreshToken) error
+	GetRefreshToken(hash string) (model.RefreshToken, error)
+	// UseRefreshToken marks the token used, it fails with model.ErrTokenNotFound if it's used or revoked already
+	UseRefreshToken(hash string) error
+	RevokeTokenFamily(family string) error
+	RevokeUserTokens(userID int) error
+	// TokenFamilyActive reports whether the family has a token which is neither revoked nor expired
+	TokenFamilyActive(family string) (bool, error)
+}
+
+type Roles interface {
+	GetRoles() ([]model.Role, error)
+	GetUserRoles(userID int) ([]model.Role, error)
+	// SetUserRoles replaces roles of the user with the named ones
+	SetUserRoles(userID int, roles []string) error
+}
+
+// UserTokens are single-use tokens mailed to users
+type UserTokens interface {
+	// CreateUserToken stores the token, unused tokens of the user with the same purpose are removed
+	CreateUserToken(token model.UserToken) error
+	// UseUserToken marks the token used and returns its user id,
+	// it fails with model.ErrInvalidToken if the token is unknown, used or expired
+	UseUserToken(purpose, hash string) (int, error)
+}
+
+// AuditLog is append-only, events are never changed or removed
+type AuditLog interface {
+	CreateAuditEvent(event model.AuditEvent) (int, error)
+	// GetAuditEvents returns a page of events, recent first, and the number of all matching events
+	GetAuditEvents(filter model.AuditFilter) ([]model.AuditEvent, int, error)
+}
+
+// Sessions describe refresh token families, a session is active while its family is
+type Sessions interface {
+	CreateSession(session model.Session) error
+	// GetSessions returns active sessions of the user, recently seen first
+	GetSessions(userID int) ([]model.Session, error)
+	// TouchSession records use of the session at most once a minute, empty IP keeps the last one
+	TouchSession(family, ip string, seenAt time.Time) error
+	// RevokeSession revokes the family if it belongs to the user
+	RevokeSession(userID int, family string) error
+}
+
+type APIKeys interface {
+	CreateAPIKey(key model.APIKey) (int, error)
+	// GetAPIKeys returns keys of the user ordered by creation
+	GetAPIKeys(userID int) ([]model.APIKey, error)
+	GetAPIKeyByHash(hash string) (model.APIKey, error)
+	TouchAPIKey(id int, usedAt time.Time) error
+	// DeleteAPIKey removes the key if it belongs to the user
+	DeleteAPIKey(userID, id int) error
+}
+
+type TwoFactor interface {
+	// GetTOTP fails with model.ErrTOTPNotEnrolled if the user has no secret
+	GetTOTP(userID int) (model.TOTP, error)
+	// SaveTOTP starts a new pending enrolment, replacing the previous one
+	SaveTOTP(userID int, secret string) error
+	// EnableTOTP activates the pending secret and replaces recovery codes with ones of the hashes
+	EnableTOTP(userID int, recoveryCodeHashes []string) error
+	// DeleteTOTP removes the secret and recovery codes
+	DeleteTOTP(userID int) error
+	// UseTOTPStep records the step of an accepted code, it fails with model.ErrInvalidCode
+	// if the step isn't newer than the last accepted one
+	UseTOTPStep(userID int, step int64) error
+	// UseRecoveryCode marks the code used, it fails with model.ErrInvalidCode if there's no such unused code
+	UseRecoveryCode(userID int, hash string) error
 }
 
 type Repository interface {
 	Storage
 	Authorization
+	Users
+	Operators
+	Tokens
+	Roles
+	TwoFactor
+	UserTokens
+	APIKeys
+	Sessions
+	AuditLog
 }
 
 func New(v *viper.Viper) (Repository, error) {
@@ -0,0 +1,439 @@
+// Package repositorytest holds conformance tests every repository driver must pass
+package repositorytest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs the suite against a repository with the schema applied.
+// Records are created with unique names and removed afterwards, so the database doesn't have to be empty.
+func Run(t *testing.T, repo repository.Repository) {
+	prefix := fmt.Sprintf("test%d", time.Now().UnixNano()%1e9)
+
+	t.Run("Users", func(t *testing.T) { testUsers(t, repo, prefix) })
+	t.Run("Operators", func(t *testing.T) { testOperators(t, repo, prefix) })
+	t.Run("Tokens", func(t *testing.T) { testTokens(t, repo, prefix) })
+	t.Run("Roles", func(t *testing.T) { testRoles(t, repo, prefix) })
+	t.Run("TOTP", func(t *testing.T) { testTOTP(t, repo, prefix) })
+	t.Run("Email", func(t *testing.T) { testEmail(t, repo, prefix) })
+	t.Run("UserTokens", func(t *testing.T) { testUserTokens(t, repo, prefix) })
+	t.Run("APIKeys", func(t *testing.T) { testAPIKeys(t, repo, prefix) })
+	t.Run("Sessions", func(t *testing.T) { testSessions(t, repo, prefix) })
+	t.Run("AuditLog", func(t *testing.T) { testAuditLog(t, repo, prefix) })
+}
+
+func testUsers(t *testing.T, repo repository.Repository, prefix string) {
+	username := prefix + "-alice"
+
+	id, err := repo.CreateUser(model.User{Name: "Alice", Username: username, Password: "hash"})
+	require.NoError(t, err)
+	require.NotZero(t, id)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(id) })
+
+	_, err = repo.CreateUser(model.User{Name: "Alice", Username: username, Password: "hash"})
+	assert.ErrorIs(t, err, model.ErrUsernameTaken)
+
+	user, err := repo.GetUserByUsername(username)
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, "hash", user.Password)
+
+	_, err = repo.GetUserByUsername(prefix + "-nobody")
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+
+	user, err = repo.GetUserByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", user.Name)
+	assert.Equal(t, username, user.Username)
+	assert.False(t, user.CreatedAt.IsZero())
+
+	_, err = repo.GetUserBySubject("")
+	assert.ErrorIs(t, err, model.ErrUserNotFound, "local users have no subject")
+
+	other, err := repo.CreateUser(model.User{Name: "Bob", Username: prefix + "-bob", Password: "hash", Subject: prefix + "-sub"})
+	require.NoError(t, err)
+
+	user, err = repo.GetUserBySubject(prefix + "-sub")
+	require.NoError(t, err)
+	assert.Equal(t, other, user.ID)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(other) })
+
+	users, total, err := repo.GetUsers(model.UserFilter{Username: prefix, Sort: "-username", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, other, users[0].ID)
+	}
+
+	name := "Alice Smith"
+	require.NoError(t, repo.UpdateUser(id, model.UserUpdate{Name: &name}))
+
+	user, err = repo.GetUserByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, name, user.Name)
+
+	assert.ErrorIs(t, repo.UpdateUser(other, model.UserUpdate{Username: &username}), model.ErrUsernameTaken)
+
+	require.NoError(t, repo.DeleteUser(id))
+	assert.ErrorIs(t, repo.DeleteUser(id), model.ErrUserNotFound)
+
+	_, err = repo.GetUserByID(id)
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+	assert.ErrorIs(t, repo.UpdateUser(id, model.UserUpdate{Name: &name}), model.ErrUserNotFound)
+}
+
+func testOperators(t *testing.T, repo repository.Repository, prefix string) {
+	login := prefix + "-op"
+
+	id, err := repo.CreateOperator(login)
+	require.NoError(t, err)
+	require.NotZero(t, id)
+
+	t.Cleanup(func() { _ = repo.DeleteOperator(id) })
+
+	_, err = repo.CreateOperator(login)
+	assert.ErrorIs(t, err, model.ErrOperatorLoginTaken)
+
+	other, err := repo.CreateOperator(prefix + "-op2")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = repo.DeleteOperator(other) })
+
+	assert.ErrorIs(t, repo.RenameOperator(other, login), model.ErrOperatorLoginTaken)
+	require.NoError(t, repo.DisableOperator(id))
+
+	operator, err := repo.GetOperator(id)
+	require.NoError(t, err)
+	assert.Equal(t, model.Operator{ID: id, Login: login, Disabled: true}, operator)
+
+	require.NoError(t, repo.DeleteOperator(id))
+	assert.ErrorIs(t, repo.DeleteOperator(id), model.ErrOperatorNotFound)
+
+	_, err = repo.GetOperator(id)
+	assert.ErrorIs(t, err, model.ErrOperatorNotFound)
+}
+
+func testTokens(t *testing.T, repo repository.Repository, prefix string) {
+	userID, err := repo.CreateUser(model.User{Name: "Token", Username: prefix + "-token", Password: "hash"})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(userID) })
+
+	expires := time.Now().Add(time.Hour)
+	for _, token := range []model.RefreshToken{
+		{UserID: userID, Family: prefix + "-a", Hash: prefix + "-a1", ExpiresAt: expires},
+		{UserID: userID, Family: prefix + "-a", Hash: prefix + "-a2", ExpiresAt: expires},
+		{UserID: userID, Family: prefix + "-b", Hash: prefix + "-b1", ExpiresAt: expires},
+		{UserID: userID, Family: prefix + "-c", Hash: prefix + "-c1", ExpiresAt: time.Now().Add(-time.Hour)},
+	} {
+		require.NoError(t, repo.CreateRefreshToken(token))
+	}
+
+	token, err := repo.GetRefreshToken(prefix + "-a1")
+	require.NoError(t, err)
+	assert.Equal(t, userID, token.UserID)
+	assert.Equal(t, prefix+"-a", token.Family)
+	assert.Nil(t, token.UsedAt)
+	assert.WithinDuration(t, expires, token.ExpiresAt, time.Second)
+
+	_, err = repo.GetRefreshToken(prefix + "-missing")
+	assert.ErrorIs(t, err, model.ErrTokenNotFound)
+
+	require.NoError(t, repo.UseRefreshToken(prefix+"-a1"))
+	assert.ErrorIs(t, repo.UseRefreshToken(prefix+"-a1"), model.ErrTokenNotFound, "tokens are used once")
+
+	token, err = repo.GetRefreshToken(prefix + "-a1")
+	require.NoError(t, err)
+	assert.NotNil(t, token.UsedAt)
+
+	for family, active := range map[string]bool{"-a": true, "-b": true, "-c": false, "-missing": false} {
+		ok, err := repo.TokenFamilyActive(prefix + family)
+		require.NoError(t, err)
+		assert.Equal(t, active, ok, family)
+	}
+
+	require.NoError(t, repo.RevokeTokenFamily(prefix+"-a"))
+	assert.ErrorIs(t, repo.UseRefreshToken(prefix+"-a2"), model.ErrTokenNotFound, "revoked tokens can't be used")
+
+	ok, err := repo.TokenFamilyActive(prefix + "-a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, repo.RevokeUserTokens(userID))
+
+	ok, err = repo.TokenFamilyActive(prefix + "-b")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testRoles(t *testing.T, repo repository.Repository, prefix string) {
+	userID, err := repo.CreateUser(model.User{Name: "Roles", Username: prefix + "-roles", Password: "hash"})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(userID) })
+
+	roles, err := repo.GetRoles()
+	require.NoError(t, err)
+
+	names, _ := model.RolePermissions(roles)
+	assert.Subset(t, names, []string{"admin", "operator", "viewer"})
+
+	require.NoError(t, repo.SetUserRoles(userID, []string{"operator", "viewer"}))
+
+	roles, err = repo.GetUserRoles(userID)
+	require.NoError(t, err)
+
+	names, permissions := model.RolePermissions(roles)
+	assert.ElementsMatch(t, []string{"operator", "viewer"}, names)
+	assert.Contains(t, permissions, model.PermOperatorsWrite)
+	assert.NotContains(t, permissions, model.PermRolesManage)
+
+	assert.ErrorIs(t, repo.SetUserRoles(userID, []string{"viewer", prefix + "-missing"}), model.ErrRoleNotFound)
+	assert.ErrorIs(t, repo.SetUserRoles(userID+1e6, []string{"viewer"}), model.ErrUserNotFound)
+
+	require.NoError(t, repo.SetUserRoles(userID, nil))
+
+	roles, err = repo.GetUserRoles(userID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+}
+
+func testTOTP(t *testing.T, repo repository.Repository, prefix string) {
+	userID, err := repo.CreateUser(model.User{Name: "TOTP", Username: prefix + "-totp", Password: "hash"})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(userID) })
+
+	_, err = repo.GetTOTP(userID)
+	assert.ErrorIs(t, err, model.ErrTOTPNotEnrolled)
+	assert.ErrorIs(t, repo.EnableTOTP(userID, nil), model.ErrTOTPNotEnrolled)
+
+	require.NoError(t, repo.SaveTOTP(userID, "FIRST"))
+	require.NoError(t, repo.SaveTOTP(userID, "SECOND"))
+
+	secret, err := repo.GetTOTP(userID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TOTP{UserID: userID, Secret: "SECOND"}, secret)
+
+	require.NoError(t, repo.EnableTOTP(userID, []string{prefix + "-r1", prefix + "-r2"}))
+
+	secret, err = repo.GetTOTP(userID)
+	require.NoError(t, err)
+	assert.NotNil(t, secret.EnabledAt)
+
+	require.NoError(t, repo.UseTOTPStep(userID, 100))
+	assert.ErrorIs(t, repo.UseTOTPStep(userID, 100), model.ErrInvalidCode)
+	assert.ErrorIs(t, repo.UseTOTPStep(userID, 99), model.ErrInvalidCode)
+	require.NoError(t, repo.UseTOTPStep(userID, 101))
+
+	require.NoError(t, repo.UseRecoveryCode(userID, prefix+"-r1"))
+	assert.ErrorIs(t, repo.UseRecoveryCode(userID, prefix+"-r1"), model.ErrInvalidCode)
+	assert.ErrorIs(t, repo.UseRecoveryCode(userID, prefix+"-missing"), model.ErrInvalidCode)
+
+	require.NoError(t, repo.DeleteTOTP(userID))
+	assert.ErrorIs(t, repo.DeleteTOTP(userID), model.ErrTOTPNotEnrolled)
+	assert.ErrorIs(t, repo.UseRecoveryCode(userID, prefix+"-r2"), model.ErrInvalidCode)
+}
+
+func testEmail(t *testing.T, repo repository.Repository, prefix string) {
+	email := prefix + "@example.com"
+
+	id, err := repo.CreateUser(model.User{Name: "Email", Username: prefix + "-email", Password: "hash", Email: email})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(id) })
+
+	noEmail, err := repo.CreateUser(model.User{Name: "No email", Username: prefix + "-noemail", Password: "hash"})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(noEmail) })
+
+	other, err := repo.CreateUser(model.User{Name: "No email", Username: prefix + "-noemail2", Password: "hash"})
+	require.NoError(t, err, "users without email don't clash")
+
+	t.Cleanup(func() { _ = repo.DeleteUser(other) })
+
+	_, err = repo.CreateUser(model.User{Name: "Email", Username: prefix + "-email2", Password: "hash", Email: email})
+	assert.ErrorIs(t, err, model.ErrEmailTaken)
+	assert.ErrorIs(t, repo.UpdateUser(other, model.UserUpdate{Email: &email}), model.ErrEmailTaken)
+
+	user, err := repo.GetUserByEmail(email)
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Nil(t, user.EmailVerifiedAt)
+
+	require.NoError(t, repo.SetEmailVerified(id))
+
+	user, err = repo.GetUserByID(id)
+	require.NoError(t, err)
+	assert.NotNil(t, user.EmailVerifiedAt)
+
+	changed := prefix + "-changed@example.com"
+	require.NoError(t, repo.UpdateUser(id, model.UserUpdate{Email: &changed}))
+
+	user, err = repo.GetUserByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, changed, user.Email)
+	assert.Nil(t, user.EmailVerifiedAt, "changed email isn't verified")
+
+	_, err = repo.GetUserByEmail(email)
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+}
+
+func testUserTokens(t *testing.T, repo repository.Repository, prefix string) {
+	userID, err := repo.CreateUser(model.User{Name: "Tokens", Username: prefix + "-usertokens", Password: "hash"})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(userID) })
+
+	expires := time.Now().Add(time.Hour)
+	for _, token := range []model.UserToken{
+		{UserID: userID, Purpose: model.TokenPasswordReset, Hash: prefix + "-reset1", ExpiresAt: expires},
+		{UserID: userID, Purpose: model.TokenPasswordReset, Hash: prefix + "-reset2", ExpiresAt: expires},
+		{UserID: userID, Purpose: model.TokenVerifyEmail, Hash: prefix + "-verify", ExpiresAt: expires},
+		{UserID: userID, Purpose: model.TokenVerifyEmail, Hash: prefix + "-expired", ExpiresAt: time.Now().Add(-time.Hour)},
+	} {
+		require.NoError(t, repo.CreateUserToken(token))
+	}
+
+	_, err = repo.UseUserToken(model.TokenPasswordReset, prefix+"-reset1")
+	assert.ErrorIs(t, err, model.ErrInvalidToken, "newer token replaces unused ones")
+
+	_, err = repo.UseUserToken(model.TokenVerifyEmail, prefix+"-reset2")
+	assert.ErrorIs(t, err, model.ErrInvalidToken, "purpose must match")
+
+	id, err := repo.UseUserToken(model.TokenPasswordReset, prefix+"-reset2")
+	require.NoError(t, err)
+	assert.Equal(t, userID, id)
+
+	_, err = repo.UseUserToken(model.TokenPasswordReset, prefix+"-reset2")
+	assert.ErrorIs(t, err, model.ErrInvalidToken, "tokens are used once")
+
+	_, err = repo.UseUserToken(model.TokenVerifyEmail, prefix+"-expired")
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+}
+
+func testAPIKeys(t *testing.T, repo repository.Repository, prefix string) {
+	userID, err := repo.CreateUser(model.User{Name: "Keys", Username: prefix + "-apikeys", Password: "hash"})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(userID) })
+
+	scopes := model.Scopes{model.PermUsersRead, model.PermOperatorsRead}
+	id, err := repo.CreateAPIKey(model.APIKey{UserID: userID, Name: "ci", Prefix: "tro_abcdefgh", Hash: prefix + "-apikey", Scopes: scopes})
+	require.NoError(t, err)
+
+	key, err := repo.GetAPIKeyByHash(prefix + "-apikey")
+	require.NoError(t, err)
+	assert.Equal(t, id, key.ID)
+	assert.Equal(t, userID, key.UserID)
+	assert.Equal(t, scopes, key.Scopes)
+	assert.Nil(t, key.LastUsedAt)
+
+	require.NoError(t, repo.TouchAPIKey(id, time.Now()))
+
+	keys, err := repo.GetAPIKeys(userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt)
+
+	assert.ErrorIs(t, repo.DeleteAPIKey(userID+1, id), model.ErrAPIKeyNotFound, "key of another user")
+	require.NoError(t, repo.DeleteAPIKey(userID, id))
+
+	_, err = repo.GetAPIKeyByHash(prefix + "-apikey")
+	assert.ErrorIs(t, err, model.ErrAPIKeyNotFound)
+}
+
+func testSessions(t *testing.T, repo repository.Repository, prefix string) {
+	userID, err := repo.CreateUser(model.User{Name: "Sessions", Username: prefix + "-sessions", Password: "hash"})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = repo.DeleteUser(userID) })
+
+	device := model.Device{Name: "Firefox on Linux", UserAgent: "Mozilla/5.0 Firefox/93.0", IP: "192.0.2.1"}
+	for _, family := range []string{prefix + "-laptop", prefix + "-phone"} {
+		require.NoError(t, repo.CreateSession(model.Session{ID: family, UserID: userID, Device: device}))
+		require.NoError(t, repo.CreateRefreshToken(model.RefreshToken{
+			UserID:    userID,
+			Family:    family,
+			Hash:      family + "-token",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}))
+	}
+
+	require.NoError(t, repo.TouchSession(prefix+"-phone", "192.0.2.2", time.Now().Add(time.Hour)))
+
+	sessions, err := repo.GetSessions(userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, prefix+"-phone", sessions[0].ID, "recently seen first")
+	assert.Equal(t, "192.0.2.2", sessions[0].IP)
+	assert.Equal(t, device, sessions[1].Device)
+
+	assert.ErrorIs(t, repo.RevokeSession(userID+1, prefix+"-phone"), model.ErrSessionNotFound, "session of another user")
+	require.NoError(t, repo.RevokeSession(userID, prefix+"-phone"))
+	assert.ErrorIs(t, repo.RevokeSession(userID, prefix+"-phone"), model.ErrSessionNotFound, "already revoked")
+
+	sessions, err = repo.GetSessions(userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, prefix+"-laptop", sessions[0].ID)
+}
+
+func testAuditLog(t *testing.T, repo repository.Repository, prefix string) {
+	// events can't be removed, so they are told from events of other runs by the target
+	start := time.Now().Add(-time.Second)
+	target := func(id int) string { return fmt.Sprintf("%s-audit:%d", prefix, id) }
+
+	for i, event := range []model.AuditEvent{
+		{Action: model.AuditSignInFailed, Target: target(1), IP: "192.0.2.1"},
+		{Action: model.AuditSignIn, Target: target(1), ActorID: 1, IP: "192.0.2.1", RequestID: "req-1"},
+		{Action: model.AuditUserUpdate, Target: target(2), ActorID: 1, ImpersonatorID: 3, Before: model.Snapshot(`{"name":"Bob"}`), After: model.Snapshot(`{"name":"Robert"}`)},
+		{Action: model.AuditUserDelete, Target: target(3), RequestID: strings.Repeat("a", requestid.MaxLength)},
+	} {
+		event.Time = start.Add(time.Duration(i) * time.Millisecond)
+		_, err := repo.CreateAuditEvent(event)
+		require.NoError(t, err)
+	}
+
+	events, total, err := repo.GetAuditEvents(model.AuditFilter{Target: target(1), Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, events, 2)
+	assert.Equal(t, model.AuditSignIn, events[0].Action, "recent first")
+	assert.Equal(t, 1, events[0].ActorID)
+	assert.Equal(t, "req-1", events[0].RequestID)
+	assert.Equal(t, 0, events[1].ActorID, "anonymous")
+
+	events, _, err = repo.GetAuditEvents(model.AuditFilter{Target: target(1), Action: "auth.", Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, model.AuditSignInFailed, events[0].Action)
+
+	events, _, err = repo.GetAuditEvents(model.AuditFilter{Target: target(2), From: start, To: time.Now().Add(time.Second), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 3, events[0].ImpersonatorID)
+	assert.JSONEq(t, `{"name":"Bob"}`, string(events[0].Before))
+	assert.JSONEq(t, `{"name":"Robert"}`, string(events[0].After))
+
+	_, total, err = repo.GetAuditEvents(model.AuditFilter{Target: target(2), From: time.Now().Add(time.Hour), Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	events, _, err = repo.GetAuditEvents(model.AuditFilter{Target: target(3), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Len(t, events[0].RequestID, requestid.MaxLength, "the longest request id is stored")
+}
@@ -0,0 +1,47 @@
+// Package sqlutil holds helpers shared by SQL repository drivers
+package sqlutil
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// Affected returns sql.ErrNoRows if the statement changed nothing
+func Affected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// NotFound replaces sql.ErrNoRows with the domain error
+func NotFound(err, notFoundErr error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFoundErr
+	}
+
+	return err
+}
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Like makes substring pattern matching the value literally
+func Like(value string) string {
+	return "%" + likeReplacer.Replace(value) + "%"
+}
+
+// LikePrefix makes prefix pattern matching the value literally
+func LikePrefix(value string) string {
+	return likeReplacer.Replace(value) + "%"
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"errors"
 	"github.com/dmytro-vovk/tro/internal/api/handler/middleware"
+	"github.com/dmytro-vovk/tro/internal/api/model"
 	"github.com/gin-gonic/gin"
 	"math/rand"
 	"net/http"
@@ -12,28 +13,77 @@ import (
 var router = struct {
 	*gin.Engine
 	once sync.Once
+	err  error
 }{
 	Engine: gin.New(),
 }
 
-func (h *Handler) Router() http.Handler {
+func (h *Handler) Router() (http.Handler, error) {
 	router.once.Do(func() {
-		router.Use(middleware.Logger(h.log))
+		// X-Forwarded-For is believed only when it's set by a trusted proxy,
+		// otherwise clients could pick the IP lockout and audit see
+		if router.err = router.SetTrustedProxies(h.trustedProxies); router.err != nil {
+			return
+		}
+
+		router.Use(middleware.RequestID(), middleware.Logger(h.log, h.logging))
+
+		router.GET("/.well-known/jwks.json", middleware.NoBodyLogging, h.auth.JWKS)
 
 		auth := router.Group("/auth")
 		{
 			auth.POST("/sign-up", h.auth.SignUp)
 			auth.POST("/sign-in", h.auth.SignIn)
+			auth.POST("/sign-in/totp", h.auth.SignInTOTP)
+			auth.POST("/refresh", h.auth.Refresh)
+			auth.POST("/logout", h.auth.UserIdentity, h.auth.RequireSession, h.auth.Logout)
+			auth.POST("/logout-all", h.auth.UserIdentity, h.auth.RequireSession, h.auth.NoImpersonation, h.auth.LogoutAll)
+			auth.POST("/password/forgot", h.auth.ForgotPassword)
+			auth.POST("/password/reset", h.auth.ResetPassword)
+			auth.GET("/verify", h.auth.VerifyEmail)
+			auth.POST("/verify", h.auth.VerifyEmail)
 		}
 
 		//api := router.Group("/api", h.auth.UserIdentity)
 		api := router.Group("/api")
 		{
 			api.GET("/hello-world", h.helloWorld)
+			api.GET("/openapi.json", middleware.NoBodyLogging, h.openAPI)
+
+			read, write := h.auth.Require(model.PermUsersRead), h.auth.Require(model.PermUsersWrite)
+			manageRoles := h.auth.Require(model.PermRolesManage)
+			impersonate := h.auth.Require(model.PermUsersImpersonate)
+
+			users := api.Group("/users", h.auth.UserIdentity)
+			{
+				users.GET("", read, h.users.List)
+				users.POST("", write, h.users.Create)
+				users.GET("/:id", read, h.users.Get)
+				users.PATCH("/:id", write, h.auth.NoImpersonation, h.users.Update)
+				users.DELETE("/:id", write, h.auth.NoImpersonation, h.users.Delete)
+				users.GET("/:id/roles", manageRoles, h.roles.GetUserRoles)
+				users.PUT("/:id/roles", manageRoles, h.auth.NoImpersonation, h.roles.SetUserRoles)
+				users.POST("/:id/impersonate", impersonate, h.auth.RequireSession, h.auth.NoImpersonation, h.auth.Impersonate)
+			}
+
+			api.GET("/roles", h.auth.UserIdentity, manageRoles, h.roles.List)
+			api.GET("/audit", h.auth.UserIdentity, h.auth.Require(model.PermAuditRead), h.audit.List)
+
+			me := api.Group("/me", h.auth.UserIdentity, h.auth.RequireSession, h.auth.NoImpersonation)
+			{
+				me.POST("/totp", h.auth.EnrolTOTP)
+				me.POST("/totp/activate", h.auth.ActivateTOTP)
+				me.POST("/totp/disable", h.auth.DisableTOTP)
+				me.GET("/sessions", h.auth.Sessions)
+				me.DELETE("/sessions/:id", h.auth.RevokeSession)
+				me.GET("/api-keys", h.keys.List)
+				me.POST("/api-keys", h.keys.Create)
+				me.DELETE("/api-keys/:id", h.keys.Revoke)
+			}
 		}
 	})
 
-	return router.Engine
+	return router.Engine, router.err
 }
 
 var counter = 0
@@ -0,0 +1,56 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWKS is JSON Web Key Set as described in RFC 7517
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a public key, RSA keys have N and E, Ed25519 keys have Crv and X
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS returns public keys, HMAC secrets are never published
+func (s *Set) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+
+	for _, id := range s.order {
+		k := s.keys[id]
+		jwk := JWK{Kid: k.id, Alg: k.method.Alg(), Use: "sig"}
+
+		switch public := k.verify.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encode(public.N.Bytes())
+			jwk.E = encode(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = encode(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
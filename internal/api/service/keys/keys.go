@@ -0,0 +1,241 @@
+// Package keys holds JWT signing and verification keys.
+// Tokens are signed with one key and verified with any configured key matched by the kid header,
+// so keys can be rotated by adding a new signing key and keeping the old one for verification until tokens expire.
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// DefaultKeyID is the id of the key made from Config.Secret
+const DefaultKeyID = "default"
+
+const (
+	defaultTTL        = 15 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrNoKeys        = errors.New("no JWT keys configured")
+	ErrUnknownKey    = errors.New("unknown JWT key")
+	ErrKeyAlgorithm  = errors.New("JWT algorithm doesn't match the key")
+	ErrNoSigningKey  = errors.New("JWT signing key has no private part")
+	errUnknownFormat = errors.New("unsupported key format")
+)
+
+type Config struct {
+	TTL        time.Duration `mapstructure:"ttl"`         // access token lifetime
+	RefreshTTL time.Duration `mapstructure:"refresh_ttl"` // refresh token lifetime
+	Secret     string        `mapstructure:"secret"`      // HS256 secret of the default key
+	SigningKey string        `mapstructure:"signing_key"` // id of the key new tokens are signed with
+	Keys       []KeyConfig   `mapstructure:"keys"`
+}
+
+// KeyConfig describes a key, HMAC keys have a secret, RSA and Ed25519 keys are read from PEM files,
+// keys without private part can only verify tokens
+type KeyConfig struct {
+	ID         string `mapstructure:"id"`
+	Algorithm  string `mapstructure:"algorithm"` // HS256, RS256 or EdDSA
+	Secret     string `mapstructure:"secret"`
+	PrivateKey string `mapstructure:"private_key"` // path to PKCS#8 or PKCS#1 PEM file
+	PublicKey  string `mapstructure:"public_key"`  // path to PKIX PEM file, derived from the private key if not set
+}
+
+type key struct {
+	id     string
+	method jwt.SigningMethod
+	sign   interface{} // nil for verification only keys
+	verify interface{}
+}
+
+type Set struct {
+	ttl        time.Duration
+	refreshTTL time.Duration
+	signing    *key
+	keys       map[string]*key
+	order      []string // key ids in configuration order
+}
+
+func New(c Config) (*Set, error) {
+	configs := c.Keys
+	if c.Secret != "" {
+		configs = append([]KeyConfig{{ID: DefaultKeyID, Algorithm: jwt.SigningMethodHS256.Alg(), Secret: c.Secret}}, configs...)
+	}
+
+	if len(configs) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	s := &Set{
+		ttl:        c.TTL,
+		refreshTTL: c.RefreshTTL,
+		keys:       make(map[string]*key, len(configs)),
+	}
+
+	if s.ttl == 0 {
+		s.ttl = defaultTTL
+	}
+
+	if s.refreshTTL == 0 {
+		s.refreshTTL = defaultRefreshTTL
+	}
+
+	for _, kc := range configs {
+		if kc.ID == "" {
+			return nil, errors.New("JWT key id is empty")
+		}
+
+		if _, ok := s.keys[kc.ID]; ok {
+			return nil, fmt.Errorf("duplicate JWT key id %q", kc.ID)
+		}
+
+		k, err := load(kc)
+		if err != nil {
+			return nil, fmt.Errorf("JWT key %q: %w", kc.ID, err)
+		}
+
+		s.keys[k.id] = k
+		s.order = append(s.order, k.id)
+	}
+
+	signing := c.SigningKey
+	if signing == "" {
+		signing = configs[0].ID
+	}
+
+	if s.signing = s.keys[signing]; s.signing == nil {
+		return nil, fmt.Errorf("%w: signing key %q", ErrUnknownKey, signing)
+	}
+
+	if s.signing.sign == nil {
+		return nil, fmt.Errorf("%w: %q", ErrNoSigningKey, signing)
+	}
+
+	return s, nil
+}
+
+// TTL is lifetime of signed tokens
+func (s *Set) TTL() time.Duration {
+	return s.ttl
+}
+
+// RefreshTTL is lifetime of refresh tokens issued along with signed ones
+func (s *Set) RefreshTTL() time.Duration {
+	return s.refreshTTL
+}
+
+// Sign signs the claims with the signing key and puts its id into kid header
+func (s *Set) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.method, claims)
+	token.Header["kid"] = s.signing.id
+
+	return token.SignedString(s.signing.sign)
+}
+
+// Keyfunc finds the key the token is signed with, tokens without kid are checked with the signing key
+func (s *Set) Keyfunc(token *jwt.Token) (interface{}, error) {
+	k := s.signing
+	if id, ok := token.Header["kid"].(string); ok {
+		if k = s.keys[id]; k == nil {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+		}
+	}
+
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, ErrKeyAlgorithm
+	}
+
+	return k.verify, nil
+}
+
+func load(c KeyConfig) (*key, error) {
+	k := &key{id: c.ID}
+
+	switch c.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		if c.Secret == "" {
+			return nil, errors.New("HMAC secret is empty")
+		}
+
+		k.method, k.sign, k.verify = jwt.SigningMethodHS256, []byte(c.Secret), []byte(c.Secret)
+	case jwt.SigningMethodRS256.Alg():
+		k.method = jwt.SigningMethodRS256
+		if err := loadPair(c, k, func(private interface{}) (interface{}, bool) {
+			p, ok := private.(*rsa.PrivateKey)
+			if !ok {
+				return nil, false
+			}
+
+			return &p.PublicKey, true
+		}); err != nil {
+			return nil, err
+		}
+
+		if _, ok := k.verify.(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("%w: RSA public key expected", errUnknownFormat)
+		}
+	case SigningMethodEdDSA.Alg():
+		k.method = SigningMethodEdDSA
+		if err := loadPair(c, k, func(private interface{}) (interface{}, bool) {
+			p, ok := private.(ed25519.PrivateKey)
+			if !ok {
+				return nil, false
+			}
+
+			return p.Public(), true
+		}); err != nil {
+			return nil, err
+		}
+
+		if _, ok := k.verify.(ed25519.PublicKey); !ok {
+			return nil, fmt.Errorf("%w: Ed25519 public key expected", errUnknownFormat)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", c.Algorithm)
+	}
+
+	return k, nil
+}
+
+// loadPair reads the private key if configured and the public key or derives it from the private one
+func loadPair(c KeyConfig, k *key, public func(private interface{}) (interface{}, bool)) error {
+	if c.PrivateKey == "" && c.PublicKey == "" {
+		return errors.New("private_key or public_key file is required")
+	}
+
+	if c.PrivateKey != "" {
+		data, err := os.ReadFile(c.PrivateKey)
+		if err != nil {
+			return err
+		}
+
+		if k.sign, err = parsePrivateKey(data); err != nil {
+			return err
+		}
+
+		var ok bool
+		if k.verify, ok = public(k.sign); !ok {
+			return fmt.Errorf("%w: %T private key for %s", errUnknownFormat, k.sign, c.Algorithm)
+		}
+	}
+
+	if c.PublicKey != "" {
+		data, err := os.ReadFile(c.PublicKey)
+		if err != nil {
+			return err
+		}
+
+		if k.verify, err = parsePublicKey(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
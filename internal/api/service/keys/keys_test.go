@@ -0,0 +1,155 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	name := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+
+	return name
+}
+
+func writePrivateKey(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return writePEM(t, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+
+	return writePEM(t, "PUBLIC KEY", der)
+}
+
+func parse(s *Set, token string) error {
+	_, err := jwt.Parse(token, s.Keyfunc)
+
+	return err
+}
+
+func TestSet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, signing := range []string{DefaultKeyID, "rsa", "ed"} {
+		t.Run(signing, func(t *testing.T) {
+			s, err := New(Config{
+				Secret:     "secret",
+				SigningKey: signing,
+				Keys: []KeyConfig{
+					{ID: "rsa", Algorithm: "RS256", PrivateKey: writePrivateKey(t, rsaKey)},
+					{ID: "ed", Algorithm: "EdDSA", PrivateKey: writePrivateKey(t, edPrivate)},
+				},
+			})
+			require.NoError(t, err)
+
+			token, err := s.Sign(jwt.MapClaims{"sub": "1"})
+			require.NoError(t, err)
+
+			parsed, _ := jwt.Parse(token, s.Keyfunc)
+			require.NotNil(t, parsed)
+			assert.True(t, parsed.Valid)
+			assert.Equal(t, signing, parsed.Header["kid"])
+		})
+	}
+
+	s, err := New(Config{Secret: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, defaultTTL, s.TTL())
+	assert.Empty(t, s.JWKS().Keys, "HMAC secrets must not be published")
+
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	assert.NoError(t, parse(s, legacy), "tokens without kid are checked with the signing key")
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"}).SignedString([]byte("other"))
+	require.NoError(t, err)
+	assert.Error(t, parse(s, forged))
+}
+
+func TestSetRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	newPublic, newPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	before, err := New(Config{Keys: []KeyConfig{
+		{ID: "old", Algorithm: "RS256", PrivateKey: writePrivateKey(t, oldKey)},
+	}})
+	require.NoError(t, err)
+
+	oldToken, err := before.Sign(jwt.MapClaims{"sub": "1"})
+	require.NoError(t, err)
+
+	after, err := New(Config{
+		SigningKey: "new",
+		Keys: []KeyConfig{
+			{ID: "old", Algorithm: "RS256", PublicKey: writePublicKey(t, &oldKey.PublicKey)},
+			{ID: "new", Algorithm: "EdDSA", PrivateKey: writePrivateKey(t, newPrivate)},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, parse(after, oldToken), "old tokens must be valid until they expire")
+
+	newToken, err := after.Sign(jwt.MapClaims{"sub": "1"})
+	require.NoError(t, err)
+	assert.Error(t, parse(before, newToken), "unknown kid")
+
+	jwks := after.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+		assert.Equal(t, "old", jwks.Keys[0].Kid)
+		assert.Equal(t, "AQAB", jwks.Keys[0].E)
+		assert.Equal(t, JWK{Kty: "OKP", Kid: "new", Alg: "EdDSA", Use: "sig", Crv: "Ed25519", X: encode(newPublic)}, jwks.Keys[1])
+	}
+
+	_, err = New(Config{SigningKey: "old", Keys: []KeyConfig{
+		{ID: "old", Algorithm: "RS256", PublicKey: writePublicKey(t, &oldKey.PublicKey)},
+	}})
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	_, err = New(Config{})
+	assert.ErrorIs(t, err, ErrNoKeys)
+}
+
+func TestKeyfuncAlgorithmMismatch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	publicFile := writePublicKey(t, &key.PublicKey)
+	s, err := New(Config{Keys: []KeyConfig{
+		{ID: "rsa", Algorithm: "RS256", PrivateKey: writePrivateKey(t, key), PublicKey: publicFile},
+	}})
+	require.NoError(t, err)
+
+	// classic attack: HMAC token signed with the public key bytes
+	public, err := os.ReadFile(publicFile)
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"})
+	token.Header["kid"] = "rsa"
+	forged, err := token.SignedString(public)
+	require.NoError(t, err)
+
+	assert.Error(t, parse(s, forged))
+}
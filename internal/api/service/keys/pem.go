@@ -0,0 +1,48 @@
+package keys
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+func decodePEM(data []byte) (*pem.Block, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", errUnknownFormat)
+	}
+
+	return block, nil
+}
+
+func parsePrivateKey(data []byte) (interface{}, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: %q PEM block", errUnknownFormat, block.Type)
+	}
+}
+
+func parsePublicKey(data []byte) (interface{}, error) {
+	block, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: %q PEM block", errUnknownFormat, block.Type)
+	}
+}
@@ -0,0 +1,96 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2id hashes passwords in PHC string format: $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+type Argon2id struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+// NewArgon2id returns hasher with parameters recommended by RFC 9106
+func NewArgon2id() *Argon2id {
+	return &Argon2id{
+		Time:    1,
+		Memory:  64 * 1024,
+		Threads: 4,
+		SaltLen: 16,
+		KeyLen:  32,
+	}
+}
+
+func (a *Argon2id) hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		a.Memory,
+		a.Time,
+		a.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *Argon2id) identifies(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func (a *Argon2id) verify(hash, password string) (bool, bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, fmt.Errorf("%w: unsupported argon2 version %q", ErrUnknownHash, parts[2])
+	}
+
+	var params Argon2id
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return false, false, fmt.Errorf("%w: %s", ErrUnknownHash, err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, fmt.Errorf("%w: %s", ErrUnknownHash, err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, fmt.Errorf("%w: %s", ErrUnknownHash, err)
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false, nil
+	}
+
+	outdated := params.Time != a.Time ||
+		params.Memory != a.Memory ||
+		params.Threads != a.Threads ||
+		uint32(len(salt)) != a.SaltLen ||
+		uint32(len(key)) != a.KeyLen
+
+	return true, outdated, nil
+}
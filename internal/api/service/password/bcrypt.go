@@ -0,0 +1,56 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes passwords in modular crypt format: $2a$<cost>$<salt and hash>
+type Bcrypt struct {
+	Cost int
+}
+
+// NewBcrypt returns hasher with the cost, zero means bcrypt.DefaultCost
+func NewBcrypt(cost int) *Bcrypt {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+
+	return &Bcrypt{Cost: cost}
+}
+
+func (b *Bcrypt) hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+
+	return string(hash), err
+}
+
+func (b *Bcrypt) identifies(hash string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *Bcrypt) verify(hash, password string) (bool, bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+
+	if err != nil {
+		return false, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, false, err
+	}
+
+	return true, cost != b.Cost, nil
+}
@@ -0,0 +1,34 @@
+package password
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const legacySalt = "kbw4Gfd45DdBdf35tsMic14"
+
+var errLegacyHash = errors.New("legacy password hashes can't be made anymore")
+
+// legacy verifies hashes of the first API version: hex encoded salt followed by SHA-1 of the password.
+// The salt wasn't hashed in, such hashes are only accepted to be upgraded on the next login.
+type legacy struct{}
+
+func (legacy) hash(string) (string, error) {
+	return "", errLegacyHash
+}
+
+func (legacy) identifies(hash string) bool {
+	prefix := hex.EncodeToString([]byte(legacySalt))
+
+	return len(hash) == len(prefix)+hex.EncodedLen(sha1.Size) && strings.HasPrefix(hash, prefix)
+}
+
+func (legacy) verify(hash, password string) (bool, bool, error) {
+	digest := sha1.Sum([]byte(password))
+	expected := hex.EncodeToString(append([]byte(legacySalt), digest[:]...))
+
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(expected)) == 1, true, nil
+}
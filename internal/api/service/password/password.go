@@ -0,0 +1,68 @@
+// Package password hashes and verifies user passwords.
+// Hashes are self-describing, they hold the algorithm and its parameters,
+// so hashes made with older algorithms or parameters can be verified and upgraded.
+package password
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher makes hashes with the preferred algorithm and verifies hashes of any supported one
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the hash and whether the hash should be replaced
+	// with a new one, because it's made by other algorithm or with outdated parameters
+	Verify(hash, password string) (ok, rehash bool, err error)
+}
+
+// algorithm is an implementation of a single hashing algorithm
+type algorithm interface {
+	hash(password string) (string, error)
+	// identifies reports whether the hash is made by this algorithm
+	identifies(hash string) bool
+	// verify reports whether the password matches the hash and whether the hash parameters are outdated
+	verify(hash, password string) (ok, outdated bool, err error)
+}
+
+type hasher struct {
+	preferred algorithm
+	known     []algorithm
+}
+
+// New returns hasher making hashes with the named algorithm, argon2id by default
+func New(name string) (Hasher, error) {
+	argon, bcrypt := NewArgon2id(), NewBcrypt(0)
+
+	switch name {
+	case "", "argon2id":
+		return &hasher{preferred: argon, known: []algorithm{argon, bcrypt, legacy{}}}, nil
+	case "bcrypt":
+		return &hasher{preferred: bcrypt, known: []algorithm{bcrypt, argon, legacy{}}}, nil
+	default:
+		return nil, fmt.Errorf("password hasher %q doesn't exist", name)
+	}
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.preferred.hash(password)
+}
+
+func (h *hasher) Verify(hash, password string) (bool, bool, error) {
+	for _, a := range h.known {
+		if !a.identifies(hash) {
+			continue
+		}
+
+		ok, outdated, err := a.verify(hash, password)
+		if err != nil || !ok {
+			return false, false, err
+		}
+
+		return true, outdated || a != h.preferred, nil
+	}
+
+	return false, false, ErrUnknownHash
+}
@@ -0,0 +1,73 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasher(t *testing.T) {
+	for _, name := range []string{"argon2id", "bcrypt"} {
+		t.Run(name, func(t *testing.T) {
+			h, err := New(name)
+			require.NoError(t, err)
+
+			hash, err := h.Hash("secret")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, "$"), "hash must be self-describing: %s", hash)
+
+			other, err := h.Hash("secret")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other, "hashes must be salted")
+
+			ok, rehash, err := h.Verify(hash, "secret")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.False(t, rehash)
+
+			ok, _, err = h.Verify(hash, "wrong")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+
+	_, err := New("md5")
+	assert.Error(t, err)
+}
+
+func TestHasherRehash(t *testing.T) {
+	h, err := New("argon2id")
+	require.NoError(t, err)
+
+	weak := &Argon2id{Time: 1, Memory: 8 * 1024, Threads: 1, SaltLen: 16, KeyLen: 32}
+	outdated, err := weak.hash("secret")
+	require.NoError(t, err)
+
+	bcrypt, err := NewBcrypt(4).hash("secret")
+	require.NoError(t, err)
+
+	testCases := map[string]string{
+		"outdated parameters": outdated,
+		"other algorithm":     bcrypt,
+		"legacy":              "6b62773447666434354464426466333574734d69633134e5e9fa1ba31ecd1ae84f75caaa474f3a663f05f4",
+	}
+
+	for name, hash := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ok, rehash, err := h.Verify(hash, "secret")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.True(t, rehash)
+
+			ok, rehash, err = h.Verify(hash, "wrong")
+			require.NoError(t, err)
+			assert.False(t, ok)
+			assert.False(t, rehash)
+		})
+	}
+
+	_, _, err = h.Verify("plain", "secret")
+	assert.ErrorIs(t, err, ErrUnknownHash)
+}
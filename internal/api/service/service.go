@@ -1,38 +1,144 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/spf13/viper"
 
 	"github.com/dmytro-vovk/tro/internal/api/model"
 	"github.com/dmytro-vovk/tro/internal/api/repository"
+	"github.com/dmytro-vovk/tro/internal/api/service/keys"
+	"github.com/dmytro-vovk/tro/internal/api/service/password"
 	v1 "github.com/dmytro-vovk/tro/internal/api/service/v1"
 	v2 "github.com/dmytro-vovk/tro/internal/api/service/v2"
+	"github.com/dmytro-vovk/tro/internal/mailer"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/service.go
 
 type Authorization interface {
-	CreateUser(user model.User) (int, error)
-	GenerateToken(username, password string) (string, error)
-	ParseToken(token string) (int, error)
+	CreateUser(ctx context.Context, user model.User) (int, error)
+	GenerateToken(ctx context.Context, username, password string, device model.Device) (model.Tokens, error)
+	RefreshToken(ctx context.Context, refreshToken string, device model.Device) (model.Tokens, error)
+	Logout(sessionID string) error
+	LogoutAll(userID int) error
+	ParseToken(ctx context.Context, token string) (model.Identity, error)
+	JWKS() keys.JWKS
+}
+
+type Users interface {
+	GetUsers(filter model.UserFilter) ([]model.User, int, error)
+	GetUserByID(id int) (model.User, error)
+	UpdateUser(ctx context.Context, id int, update model.UserUpdate) error
+	DeleteUser(id int) error
+}
+
+type Roles interface {
+	GetRoles() ([]model.Role, error)
+	GetUserRoles(userID int) ([]model.Role, error)
+	SetUserRoles(userID int, roles []string) error
+}
+
+// TwoFactor manages TOTP second factor of users
+type TwoFactor interface {
+	EnrolTOTP(userID int) (model.TOTPEnrolment, error)
+	// ActivateTOTP enables the pending enrolment and returns recovery codes
+	ActivateTOTP(userID int, code string) ([]string, error)
+	// DisableTOTP removes the second factor, code is a TOTP or recovery code
+	DisableTOTP(userID int, code string) error
+	// SignInTOTP exchanges MFA token issued on sign-in and the code for tokens
+	SignInTOTP(mfaToken, code string, device model.Device) (model.Tokens, error)
+	// ChallengedUser returns the user the MFA token is issued to, e.g. to limit attempts of the account
+	ChallengedUser(mfaToken string) (model.User, error)
+}
+
+// Recovery confirms emails and resets forgotten passwords with mailed single-use tokens
+type Recovery interface {
+	// ForgotPassword mails a reset token if the email belongs to a user, unknown emails aren't reported
+	ForgotPassword(ctx context.Context, email string) error
+	// ResetPassword sets the password and ends all sessions of the user
+	ResetPassword(token, password string) error
+	VerifyEmail(token string) error
+}
+
+// Sessions lets users see where they are signed in and end sessions
+type Sessions interface {
+	GetSessions(userID int) ([]model.Session, error)
+	RevokeSession(userID int, sessionID string) error
+	// OnRevoke sets the function told about every ended session, e.g. to close its connections
+	OnRevoke(fn func(model.Revocation))
+}
+
+// Impersonation lets support staff see the system as a user does
+type Impersonation interface {
+	// Impersonate issues short-lived access token of the user to the admin, without refresh token
+	Impersonate(admin model.Identity, userID int) (model.Tokens, error)
+}
+
+// APIKeys manages long-lived keys users authenticate programs with
+type APIKeys interface {
+	// CreateAPIKey returns the key, it can't be retrieved later
+	CreateAPIKey(userID int, name string, scopes []model.Permission) (model.APIKey, string, error)
+	GetAPIKeys(userID int) ([]model.APIKey, error)
+	RevokeAPIKey(userID, id int) error
+}
+
+// Auditor keeps the append-only log of important actions
+type Auditor interface {
+	// Audit records the event, failing to record doesn't fail the action, so errors are only logged
+	Audit(event model.AuditEvent)
+	GetAuditEvents(filter model.AuditFilter) ([]model.AuditEvent, int, error)
 }
 
 type Service interface {
 	Authorization
+	Sessions
+	Impersonation
+	APIKeys
+	TwoFactor
+	Recovery
+	Users
+	Roles
 }
 
-func New(db repository.Repository, v *viper.Viper) (Service, error) {
+func New(db repository.Repository, mail mailer.Mailer, v *viper.Viper) (Service, error) {
 	if v == nil {
 		return nil, errors.New("API configuration not provided")
 	}
 
 	switch method := v.GetString("auth_method"); method {
 	case "jwt":
-		return v1.New(db), nil
+		passwords, err := password.New(v.GetString("password_hasher"))
+		if err != nil {
+			return nil, err
+		}
+
+		var config keys.Config
+		if err := v.UnmarshalKey("jwt", &config); err != nil {
+			return nil, fmt.Errorf("can't unmarshall JWT config: %w", err)
+		}
+
+		tokens, err := keys.New(config)
+		if errors.Is(err, keys.ErrNoKeys) {
+			return nil, fmt.Errorf("%w, set JWT_SECRET (e.g. in .env) or api.jwt.keys", err)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return v1.New(db, passwords, tokens, mail, v1.Config{
+			TOTPIssuer: v.GetString("totp_issuer"),
+			PublicURL:  v.GetString("public_url"),
+		}), nil
 	case "avigilon":
-		return v2.New(db), nil
+		var config v2.Config
+		if err := v.UnmarshalKey("avigilon", &config); err != nil {
+			return nil, fmt.Errorf("can't unmarshall avigilon config: %w", err)
+		}
+
+		return v2.New(db, config), nil
 	default:
 		return nil, fmt.Errorf("authorization method %q doesn't exist", method)
 	}
@@ -0,0 +1,63 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 key of RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, last 6 of 8 digits
+	for unix, want := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		code, err := Code(rfcSecret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	code, err := Code(secret, now.Add(-Period))
+	require.NoError(t, err)
+
+	step, ok, err := Validate(secret, code, now)
+	require.NoError(t, err)
+	assert.True(t, ok, "previous step is accepted")
+	assert.Equal(t, now.Unix()/30-1, step)
+
+	code, err = Code(secret, now.Add(-3*Period))
+	require.NoError(t, err)
+
+	_, ok, err = Validate(secret, code, now)
+	require.NoError(t, err)
+	assert.False(t, ok, "stale codes are rejected")
+
+	_, ok, _ = Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("TRO", "alice", "SECRET")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/TRO:alice?"), uri)
+	assert.Contains(t, uri, "secret=SECRET")
+	assert.Contains(t, uri, "issuer=TRO")
+}
@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+)
+
+const (
+	apiKeyPrefixSize = 8           // characters of the key after model.APIKeyPrefix kept in clear to recognize the key
+	apiKeyTouchEvery = time.Minute // last use time isn't written on every request
+)
+
+// CreateAPIKey makes a key with the scopes, the key itself is returned only here
+func (s *service) CreateAPIKey(userID int, name string, scopes []model.Permission) (model.APIKey, string, error) {
+	scopes, err := validScopes(scopes)
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+
+	secret, err := randomString(32)
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+
+	key := model.APIKeyPrefix + secret
+	apiKey := model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:len(model.APIKeyPrefix)+apiKeyPrefixSize],
+		Hash:      hashToken(key),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+
+	if apiKey.ID, err = s.db.CreateAPIKey(apiKey); err != nil {
+		return model.APIKey{}, "", err
+	}
+
+	return apiKey, key, nil
+}
+
+func (s *service) GetAPIKeys(userID int) ([]model.APIKey, error) {
+	return s.db.GetAPIKeys(userID)
+}
+
+func (s *service) RevokeAPIKey(userID, id int) error {
+	if err := s.db.DeleteAPIKey(userID, id); err != nil {
+		return err
+	}
+
+	s.revoked(model.Revocation{UserID: userID, APIKeyID: id})
+
+	return nil
+}
+
+// parseAPIKey makes identity of the key owner limited to the key scopes,
+// roles are read on every request, so the key never has more than the user
+func (s *service) parseAPIKey(ctx context.Context, key string) (model.Identity, error) {
+	apiKey, err := s.db.GetAPIKeyByHash(hashToken(key))
+	if errors.Is(err, model.ErrAPIKeyNotFound) {
+		return model.Identity{}, model.ErrInvalidToken
+	}
+
+	if err != nil {
+		return model.Identity{}, err
+	}
+
+	userRoles, err := s.db.GetUserRoles(apiKey.UserID)
+	if err != nil {
+		return model.Identity{}, err
+	}
+
+	roles, permissions := model.RolePermissions(userRoles)
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchEvery {
+		if err := s.db.TouchAPIKey(apiKey.ID, now); err != nil {
+			requestid.Log(ctx).Errorf("Error recording use of API key %d: %s", apiKey.ID, err)
+		}
+	}
+
+	return model.Identity{
+		UserID:      apiKey.UserID,
+		APIKeyID:    apiKey.ID,
+		Roles:       roles,
+		Permissions: intersect(permissions, apiKey.Scopes),
+	}, nil
+}
+
+// validScopes drops duplicates and rejects unknown permissions
+func validScopes(scopes []model.Permission) ([]model.Permission, error) {
+	if len(scopes) == 0 {
+		return nil, model.ErrUnknownPermission
+	}
+
+	valid := make([]model.Permission, 0, len(scopes))
+	for _, scope := range scopes {
+		if len(intersect(model.Permissions, []model.Permission{scope})) == 0 {
+			return nil, model.ErrUnknownPermission
+		}
+
+		if len(intersect(valid, []model.Permission{scope})) == 0 {
+			valid = append(valid, scope)
+		}
+	}
+
+	return valid, nil
+}
+
+func intersect(a, b []model.Permission) []model.Permission {
+	result := []model.Permission{}
+	for _, p := range a {
+		for _, q := range b {
+			if p == q {
+				result = append(result, p)
+				break
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,118 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *memRepository) CreateAPIKey(key model.APIKey) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key.ID = len(m.apiKeys) + 1
+	m.apiKeys = append(m.apiKeys, &key)
+
+	return key.ID, nil
+}
+
+func (m *memRepository) GetAPIKeys(userID int) ([]model.APIKey, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	keys := []model.APIKey{}
+	for _, k := range m.apiKeys {
+		if k != nil && k.UserID == userID {
+			keys = append(keys, *k)
+		}
+	}
+
+	return keys, nil
+}
+
+func (m *memRepository) GetAPIKeyByHash(hash string) (model.APIKey, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, k := range m.apiKeys {
+		if k != nil && k.Hash == hash {
+			return *k, nil
+		}
+	}
+
+	return model.APIKey{}, model.ErrAPIKeyNotFound
+}
+
+func (m *memRepository) TouchAPIKey(id int, usedAt time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.apiKeys[id-1].LastUsedAt = &usedAt
+
+	return nil
+}
+
+func (m *memRepository) DeleteAPIKey(userID, id int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if id > len(m.apiKeys) || m.apiKeys[id-1] == nil || m.apiKeys[id-1].UserID != userID {
+		return model.ErrAPIKeyNotFound
+	}
+
+	m.apiKeys[id-1] = nil
+
+	return nil
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+
+	s, repo := newTestService(t)
+
+	repo.roles[1] = []model.Role{
+		{Name: "viewer", Permissions: []model.Permission{model.PermUsersRead, model.PermOperatorsRead}},
+	}
+
+	_, _, err := s.CreateAPIKey(1, "ci", nil)
+	assert.ErrorIs(t, err, model.ErrUnknownPermission, "a key needs scopes")
+
+	_, _, err = s.CreateAPIKey(1, "ci", []model.Permission{"users:everything"})
+	assert.ErrorIs(t, err, model.ErrUnknownPermission)
+
+	apiKey, key, err := s.CreateAPIKey(1, "ci", []model.Permission{model.PermUsersRead, model.PermUsersWrite, model.PermUsersRead})
+	require.NoError(t, err)
+	assert.Equal(t, model.Scopes{model.PermUsersRead, model.PermUsersWrite}, apiKey.Scopes)
+	assert.True(t, len(key) > len(apiKey.Prefix))
+	assert.Equal(t, apiKey.Prefix, key[:len(apiKey.Prefix)])
+	assert.NotContains(t, apiKey.Hash, key)
+
+	identity, err := s.ParseToken(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 1, identity.UserID)
+	assert.Equal(t, apiKey.ID, identity.APIKeyID)
+	assert.Empty(t, identity.SessionID)
+	assert.Equal(t, []model.Permission{model.PermUsersRead}, identity.Permissions, "scopes are limited to the user's permissions")
+
+	keys, err := s.GetAPIKeys(1)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NotNil(t, keys[0].LastUsedAt, "use is recorded")
+
+	_, err = s.ParseToken(ctx, key+"x")
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+
+	var revocations []model.Revocation
+	s.OnRevoke(func(r model.Revocation) { revocations = append(revocations, r) })
+
+	assert.ErrorIs(t, s.RevokeAPIKey(2, apiKey.ID), model.ErrAPIKeyNotFound, "keys of other users can't be revoked")
+	require.NoError(t, s.RevokeAPIKey(1, apiKey.ID))
+	assert.Equal(t, []model.Revocation{{UserID: 1, APIKeyID: apiKey.ID}}, revocations, "connections of the key are closed")
+
+	_, err = s.ParseToken(ctx, key)
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+}
@@ -1,73 +1,165 @@
 package v1
 
 import (
-	"crypto/sha1"
+	"context"
 	"errors"
-	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/dmytro-vovk/tro/internal/api/model"
-)
-
-const (
-	salt       = "kbw4Gfd45DdBdf35tsMic14"
-	signingKey = "A24njRJ4bUks9DmRfs23FDp"
-	tokenTTL   = 12 * time.Hour
+	"github.com/dmytro-vovk/tro/internal/api/service/keys"
+	"github.com/dmytro-vovk/tro/internal/api/service/password"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/sirupsen/logrus"
 )
 
 type tokenClaims struct {
 	jwt.StandardClaims
-	UserID int `json:"user_id"`
+	UserID      int                `json:"user_id"`
+	SessionID   string             `json:"sid"` // refresh token family, revoked sessions invalidate their access tokens
+	Roles       []string           `json:"roles,omitempty"`
+	Permissions []model.Permission `json:"permissions,omitempty"`
+	// ImpersonatorID is the admin the token is issued to on behalf of the user, such tokens can't be refreshed
+	ImpersonatorID int `json:"impersonator_id,omitempty"`
 }
 
-func (s *service) CreateUser(user model.User) (int, error) {
-	user.Password = s.generatePasswordHash(user.Password)
+func (s *service) CreateUser(ctx context.Context, user model.User) (int, error) {
+	hash, err := s.passwords.Hash(user.Password)
+	if err != nil {
+		return 0, err
+	}
 
-	return s.db.CreateUser(user)
-}
+	user.Password = hash
+
+	id, err := s.db.CreateUser(user)
+	if err != nil {
+		return 0, err
+	}
 
-func (s *service) generatePasswordHash(password string) string {
-	hash := sha1.New()
-	hash.Write([]byte(password))
+	if user.Email != "" {
+		s.sendVerification(ctx, id, user.Email)
+	}
 
-	return fmt.Sprintf("%x", hash.Sum([]byte(salt)))
+	return id, nil
 }
 
-func (s *service) GenerateToken(username, password string) (string, error) {
-	user, err := s.db.GetUser(username, s.generatePasswordHash(password))
+// GenerateToken starts a new session from the device
+func (s *service) GenerateToken(ctx context.Context, username, password string, device model.Device) (model.Tokens, error) {
+	user, err := s.authenticate(ctx, username, password)
 	if err != nil {
-		return "", err
+		return model.Tokens{}, err
 	}
 
-	now := time.Now()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: now.Add(tokenTTL).Unix(),
-			IssuedAt:  now.Unix(),
-		},
-		UserID: user.ID,
-	})
+	if enabled, err := s.totpEnabled(user.ID); err != nil {
+		return model.Tokens{}, err
+	} else if enabled {
+		return s.challenge(user.ID)
+	}
 
-	return token.SignedString([]byte(signingKey))
+	return s.start(user.ID, device)
 }
 
-func (s *service) ParseToken(accessToken string) (int, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
+// authenticate verifies the password and upgrades its hash if it's made by outdated hasher
+func (s *service) authenticate(ctx context.Context, username, plain string) (model.User, error) {
+	user, err := s.db.GetUserByUsername(username)
+	if errors.Is(err, model.ErrUserNotFound) {
+		// take as long as checking a password, so response time doesn't tell whether the username exists
+		_, _, _ = s.passwords.Verify(s.dummyHash(), plain)
+
+		return model.User{}, model.ErrInvalidCredentials
+	}
+
+	if err != nil {
+		return model.User{}, err
+	}
+
+	ok, rehash, err := s.passwords.Verify(user.Password, plain)
+	if errors.Is(err, password.ErrUnknownHash) {
+		// users provisioned by other auth method have no local password
+		return model.User{}, model.ErrInvalidCredentials
+	}
+
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if !ok {
+		return model.User{}, model.ErrInvalidCredentials
+	}
+
+	if rehash {
+		if hash, err := s.passwords.Hash(plain); err != nil {
+			requestid.Log(ctx).Errorf("Error rehashing password of user %d: %s", user.ID, err)
+		} else if err := s.db.UpdateUser(user.ID, model.UserUpdate{Password: &hash}); err != nil {
+			requestid.Log(ctx).Errorf("Error upgrading password hash of user %d: %s", user.ID, err)
 		}
+	}
 
-		return []byte(signingKey), nil
-	})
+	return user, nil
+}
+
+func (s *service) ParseToken(ctx context.Context, accessToken string) (model.Identity, error) {
+	if strings.HasPrefix(accessToken, model.APIKeyPrefix) {
+		return s.parseAPIKey(ctx, accessToken)
+	}
+
+	token, err := jwt.ParseWithClaims(accessToken, &tokenClaims{}, s.tokens.Keyfunc)
 	if err != nil {
-		return 0, err
+		return model.Identity{}, err
 	}
 
 	claims, ok := token.Claims.(*tokenClaims)
 	if !ok {
-		return 0, errors.New("token claims aren't of type")
+		return model.Identity{}, errors.New("token claims aren't of type")
+	}
+
+	if claims.SessionID == "" {
+		return model.Identity{}, model.ErrInvalidToken
+	}
+
+	active, err := s.db.TokenFamilyActive(claims.SessionID)
+	if err != nil {
+		return model.Identity{}, err
 	}
 
-	return claims.UserID, nil
+	if !active {
+		return model.Identity{}, model.ErrInvalidToken
+	}
+
+	s.used(ctx, claims.SessionID)
+
+	identity := model.Identity{
+		UserID:         claims.UserID,
+		SessionID:      claims.SessionID,
+		ImpersonatorID: claims.ImpersonatorID,
+		Roles:          claims.Roles,
+		Permissions:    claims.Permissions,
+	}
+
+	if claims.ExpiresAt != 0 {
+		identity.ExpiresAt = time.Unix(claims.ExpiresAt, 0)
+	}
+
+	return identity, nil
+}
+
+func (s *service) JWKS() keys.JWKS {
+	return s.tokens.JWKS()
+}
+
+// dummyHash is a hash of a random password made by the preferred hasher
+func (s *service) dummyHash() string {
+	s.dummy.Do(func() {
+		secret, err := randomString(16)
+		if err == nil {
+			s.dummy.hash, err = s.passwords.Hash(secret)
+		}
+
+		if err != nil {
+			logrus.Errorf("Error making dummy password hash: %s", err)
+		}
+	})
+
+	return s.dummy.hash
 }
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dmytro-vovk/tro/internal/api/model"
+)
+
+const impersonationTTL = 15 * time.Minute
+
+// Impersonate issues the admin an access token of the user. It belongs to the admin's session,
+// so logging the admin out ends impersonation too. Users having permissions the admin lacks
+// can't be impersonated, so impersonation grants nothing new.
+func (s *service) Impersonate(admin model.Identity, userID int) (model.Tokens, error) {
+	if admin.SessionID == "" || admin.APIKeyID != 0 || admin.ImpersonatorID != 0 || admin.UserID == userID {
+		return model.Tokens{}, model.ErrImpersonation
+	}
+
+	if _, err := s.db.GetUserByID(userID); err != nil {
+		return model.Tokens{}, err
+	}
+
+	userRoles, err := s.db.GetUserRoles(userID)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	roles, permissions := model.RolePermissions(userRoles)
+	if !admin.Can(permissions...) {
+		return model.Tokens{}, model.ErrImpersonation
+	}
+
+	now := time.Now()
+
+	accessToken, err := s.tokens.Sign(&tokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: now.Add(impersonationTTL).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		UserID:         userID,
+		SessionID:      admin.SessionID,
+		Roles:          roles,
+		Permissions:    permissions,
+		ImpersonatorID: admin.UserID,
+	})
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	return model.Tokens{
+		AccessToken: accessToken,
+		ExpiresIn:   int(impersonationTTL.Seconds()),
+	}, nil
+}
@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonate(t *testing.T) {
+	ctx := context.Background()
+
+	s, repo := newTestService(t)
+
+	repo.roles[1] = []model.Role{{Name: "support", Permissions: []model.Permission{model.PermUsersRead, model.PermUsersImpersonate}}}
+
+	bob, err := s.CreateUser(ctx, model.User{Name: "Bob", Username: "bob", Password: "secret"})
+	require.NoError(t, err)
+
+	repo.roles[bob] = []model.Role{{Name: "viewer", Permissions: []model.Permission{model.PermUsersRead}}}
+
+	carol, err := s.CreateUser(ctx, model.User{Name: "Carol", Username: "carol", Password: "secret"})
+	require.NoError(t, err)
+
+	repo.roles[carol] = []model.Role{{Name: "admin", Permissions: []model.Permission{model.PermRolesManage}}}
+
+	tokens, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+
+	admin, err := s.ParseToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+
+	_, err = s.Impersonate(admin, carol)
+	assert.ErrorIs(t, err, model.ErrImpersonation, "carol has permissions alice lacks")
+
+	_, err = s.Impersonate(admin, admin.UserID)
+	assert.ErrorIs(t, err, model.ErrImpersonation)
+
+	_, err = s.Impersonate(admin, 42)
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+
+	impersonated, err := s.Impersonate(admin, bob)
+	require.NoError(t, err)
+	assert.Empty(t, impersonated.RefreshToken, "impersonation can't be prolonged")
+	assert.Equal(t, int(impersonationTTL.Seconds()), impersonated.ExpiresIn)
+
+	identity, err := s.ParseToken(ctx, impersonated.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, bob, identity.UserID)
+	assert.Equal(t, admin.UserID, identity.ImpersonatorID)
+	assert.Equal(t, []string{"viewer"}, identity.Roles)
+	assert.Equal(t, []model.Permission{model.PermUsersRead}, identity.Permissions)
+
+	_, err = s.Impersonate(identity, carol)
+	assert.ErrorIs(t, err, model.ErrImpersonation, "impersonation doesn't nest")
+
+	require.NoError(t, s.Logout(admin.SessionID))
+
+	_, err = s.ParseToken(ctx, impersonated.AccessToken)
+	assert.ErrorIs(t, err, model.ErrInvalidToken, "impersonation ends with the admin's session")
+}
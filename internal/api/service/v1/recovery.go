@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/mailer"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+)
+
+const (
+	resetTokenTTL  = time.Hour
+	verifyTokenTTL = 48 * time.Hour
+)
+
+// ForgotPassword mails the token in background, so response time doesn't tell whether the email is known
+func (s *service) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.db.GetUserByEmail(email)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		token, err := s.userToken(user.ID, model.TokenPasswordReset, resetTokenTTL)
+		if err != nil {
+			requestid.Log(ctx).Errorf("Error making password reset token of user %d: %s", user.ID, err)
+			return
+		}
+
+		s.send(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Password reset",
+			Body: fmt.Sprintf(
+				"Someone, hopefully you, asked to reset the password of %s.\n\n"+
+					"Reset token: %s\n\nIt's valid for %s. If it wasn't you, ignore this message.\n",
+				user.Username, token, resetTokenTTL,
+			),
+		})
+	}()
+
+	return nil
+}
+
+func (s *service) ResetPassword(token, password string) error {
+	userID, err := s.db.UseUserToken(model.TokenPasswordReset, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	hash, err := s.passwords.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.UpdateUser(userID, model.UserUpdate{Password: &hash}); err != nil {
+		return err
+	}
+
+	// the token came by mail, so the email is confirmed too
+	if err := s.db.SetEmailVerified(userID); err != nil {
+		return err
+	}
+
+	return s.LogoutAll(userID)
+}
+
+func (s *service) VerifyEmail(token string) error {
+	userID, err := s.db.UseUserToken(model.TokenVerifyEmail, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	return s.db.SetEmailVerified(userID)
+}
+
+// sendVerification mails email confirmation link, failures are logged, the user can change the email to get another one
+func (s *service) sendVerification(ctx context.Context, userID int, email string) {
+	token, err := s.userToken(userID, model.TokenVerifyEmail, verifyTokenTTL)
+	if err != nil {
+		requestid.Log(ctx).Errorf("Error making email verification token of user %d: %s", userID, err)
+		return
+	}
+
+	s.send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf(
+			"Follow the link to confirm your email:\n\n%s/auth/verify?token=%s\n\nIt's valid for %s.\n",
+			s.publicURL, token, verifyTokenTTL,
+		),
+	})
+}
+
+// userToken stores a new single-use token and returns it
+func (s *service) userToken(userID int, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.db.CreateUserToken(model.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		Hash:      hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *service) send(ctx context.Context, m mailer.Message) {
+	if err := s.mail.Send(m); err != nil {
+		requestid.Log(ctx).Errorf("Error mailing %q to %s: %s", m.Subject, m.To, err)
+	}
+}
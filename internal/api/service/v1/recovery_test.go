@@ -0,0 +1,152 @@
+package v1
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *memRepository) GetUserByEmail(email string) (model.User, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, u := range m.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+
+	return model.User{}, model.ErrUserNotFound
+}
+
+func (m *memRepository) UpdateUser(id int, update model.UserUpdate) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if update.Password != nil {
+		m.users[id-1].Password = *update.Password
+	}
+
+	return nil
+}
+
+func (m *memRepository) SetEmailVerified(userID int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	m.users[userID-1].EmailVerifiedAt = &now
+
+	return nil
+}
+
+func (m *memRepository) CreateUserToken(token model.UserToken) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for hash, t := range m.userTokens {
+		if t.UserID == token.UserID && t.Purpose == token.Purpose {
+			delete(m.userTokens, hash)
+		}
+	}
+
+	m.userTokens[token.Hash] = &token
+
+	return nil
+}
+
+func (m *memRepository) UseUserToken(purpose, hash string) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	t, ok := m.userTokens[hash]
+	if !ok || t.Purpose != purpose || !time.Now().Before(t.ExpiresAt) {
+		return 0, model.ErrInvalidToken
+	}
+
+	delete(m.userTokens, hash)
+
+	return t.UserID, nil
+}
+
+type mailbox chan mailer.Message
+
+func (m mailbox) Send(msg mailer.Message) error {
+	m <- msg
+
+	return nil
+}
+
+func (m mailbox) token(t *testing.T, pattern string) string {
+	t.Helper()
+
+	select {
+	case msg := <-m:
+		match := regexp.MustCompile(pattern).FindStringSubmatch(msg.Body)
+		require.NotNil(t, match, msg.Body)
+
+		return match[1]
+	case <-time.After(time.Second):
+		t.Fatal("No mail sent")
+		return ""
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+
+	s, repo := newTestService(t)
+	mail := make(mailbox, 1)
+	s.mail = mail
+
+	id, err := s.CreateUser(ctx, model.User{Name: "Bob", Username: "bob", Password: "secret", Email: "bob@example.com"})
+	require.NoError(t, err)
+
+	token := mail.token(t, `https://tro\.example\.com/auth/verify\?token=(\S+)`)
+
+	assert.ErrorIs(t, s.VerifyEmail("forged"), model.ErrInvalidToken)
+	require.NoError(t, s.VerifyEmail(token))
+	assert.NotNil(t, repo.users[id-1].EmailVerifiedAt)
+	assert.ErrorIs(t, s.VerifyEmail(token), model.ErrInvalidToken, "tokens are used once")
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+
+	s, repo := newTestService(t)
+	mail := make(mailbox, 1)
+	s.mail = mail
+
+	repo.users[0].Email = "alice@example.com"
+
+	session, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+
+	require.NoError(t, s.ForgotPassword(ctx, "nobody@example.com"), "unknown emails aren't reported")
+	require.NoError(t, s.ForgotPassword(ctx, "alice@example.com"))
+	first := mail.token(t, `Reset token: (\S+)`)
+
+	require.NoError(t, s.ForgotPassword(ctx, "alice@example.com"))
+	second := mail.token(t, `Reset token: (\S+)`)
+
+	assert.ErrorIs(t, s.ResetPassword(first, "new secret"), model.ErrInvalidToken, "new token replaces older ones")
+	assert.ErrorIs(t, s.VerifyEmail(second), model.ErrInvalidToken, "tokens have purpose")
+	require.NoError(t, s.ResetPassword(second, "new secret"))
+	assert.ErrorIs(t, s.ResetPassword(second, "other secret"), model.ErrInvalidToken)
+
+	_, err = s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+
+	_, err = s.GenerateToken(ctx, "alice", "new secret", model.Device{})
+	assert.NoError(t, err)
+
+	_, err = s.ParseToken(ctx, session.AccessToken)
+	assert.ErrorIs(t, err, model.ErrInvalidToken, "reset ends all sessions")
+	assert.NotNil(t, repo.users[0].EmailVerifiedAt)
+}
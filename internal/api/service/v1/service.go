@@ -1,11 +1,57 @@
 package v1
 
-import "github.com/dmytro-vovk/tro/internal/api/repository"
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository"
+	"github.com/dmytro-vovk/tro/internal/api/service/keys"
+	"github.com/dmytro-vovk/tro/internal/api/service/password"
+	"github.com/dmytro-vovk/tro/internal/mailer"
+)
+
+const defaultIssuer = "TRO"
+
+type Config struct {
+	TOTPIssuer string // shown in authenticator apps
+	PublicURL  string // base URL of the API links in mail point to
+}
 
 type service struct {
-	db repository.Repository
+	db        repository.Repository
+	passwords password.Hasher
+	tokens    *keys.Set
+	mail      mailer.Mailer
+	issuer    string
+	publicURL string
+	dummy     struct {
+		sync.Once
+		hash string
+	}
+	revoke struct {
+		sync.RWMutex
+		fn func(model.Revocation)
+	}
+	seen struct { // when requests of sessions were last recorded
+		sync.Mutex
+		at     map[string]time.Time
+		pruned time.Time
+	}
 }
 
-func New(db repository.Repository) *service {
-	return &service{db: db}
+func New(db repository.Repository, passwords password.Hasher, tokens *keys.Set, mail mailer.Mailer, c Config) *service {
+	if c.TOTPIssuer == "" {
+		c.TOTPIssuer = defaultIssuer
+	}
+
+	return &service{
+		db:        db,
+		passwords: passwords,
+		tokens:    tokens,
+		mail:      mail,
+		issuer:    c.TOTPIssuer,
+		publicURL: strings.TrimSuffix(c.PublicURL, "/"),
+	}
 }
@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+)
+
+// limits of the sessions table columns
+const (
+	maxDeviceName = 255
+	maxUserAgent  = 500
+	maxIP         = 45
+)
+
+const sessionTouchEvery = time.Minute // last seen time isn't written on every request
+
+func (s *service) GetSessions(userID int) ([]model.Session, error) {
+	return s.db.GetSessions(userID)
+}
+
+func (s *service) RevokeSession(userID int, sessionID string) error {
+	if err := s.db.RevokeSession(userID, sessionID); err != nil {
+		return err
+	}
+
+	s.revoked(model.Revocation{UserID: userID, SessionID: sessionID})
+
+	return nil
+}
+
+// OnRevoke sets the function told about every revoked session, e.g. to close its connections
+func (s *service) OnRevoke(fn func(model.Revocation)) {
+	s.revoke.Lock()
+	s.revoke.fn = fn
+	s.revoke.Unlock()
+}
+
+func (s *service) revoked(r model.Revocation) {
+	s.revoke.RLock()
+	fn := s.revoke.fn
+	s.revoke.RUnlock()
+
+	if fn != nil {
+		fn(r)
+	}
+}
+
+// start begins a new session from the device
+func (s *service) start(userID int, device model.Device) (model.Tokens, error) {
+	family, err := randomString(16)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	if err := s.db.CreateSession(model.Session{
+		ID:     family,
+		UserID: userID,
+		Device: model.Device{
+			Name:      truncate(device.Name, maxDeviceName),
+			UserAgent: truncate(device.UserAgent, maxUserAgent),
+			IP:        truncate(device.IP, maxIP),
+		},
+	}); err != nil {
+		return model.Tokens{}, err
+	}
+
+	return s.issue(userID, family)
+}
+
+// used records use of the session by a request, at most once a minute
+func (s *service) used(ctx context.Context, family string) {
+	now := time.Now()
+
+	s.seen.Lock()
+	if s.seen.at == nil {
+		s.seen.at = make(map[string]time.Time)
+	}
+
+	if now.Sub(s.seen.pruned) >= sessionTouchEvery {
+		for f, at := range s.seen.at {
+			if now.Sub(at) >= sessionTouchEvery {
+				delete(s.seen.at, f)
+			}
+		}
+
+		s.seen.pruned = now
+	}
+
+	_, recent := s.seen.at[family]
+	if !recent {
+		s.seen.at[family] = now
+	}
+	s.seen.Unlock()
+
+	if !recent {
+		s.touch(ctx, family, "")
+	}
+}
+
+// touch records use of the session, it mustn't fail requests
+func (s *service) touch(ctx context.Context, family, ip string) {
+	if err := s.db.TouchSession(family, truncate(ip, maxIP), time.Now()); err != nil {
+		requestid.Log(ctx).Errorf("Error recording use of session %s: %s", family, err)
+	}
+}
+
+func truncate(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+
+	s = s[:size]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+
+	return s
+}
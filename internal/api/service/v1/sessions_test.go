@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *memRepository) CreateSession(session model.Session) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	session.CreatedAt, session.LastSeenAt = time.Now(), time.Now()
+	m.sessions[session.ID] = &session
+
+	return nil
+}
+
+func (m *memRepository) GetSessions(userID int) ([]model.Session, error) {
+	sessions := []model.Session{}
+	for _, s := range m.sessions {
+		if active, _ := m.TokenFamilyActive(s.ID); active && s.UserID == userID {
+			sessions = append(sessions, *s)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (m *memRepository) TouchSession(family, ip string, seenAt time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.touches++
+	if s, ok := m.sessions[family]; ok {
+		s.LastSeenAt = seenAt
+		if ip != "" {
+			s.IP = ip
+		}
+	}
+
+	return nil
+}
+
+func (m *memRepository) RevokeSession(userID int, family string) error {
+	if active, _ := m.TokenFamilyActive(family); !active || m.sessions[family].UserID != userID {
+		return model.ErrSessionNotFound
+	}
+
+	return m.RevokeTokenFamily(family)
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+
+	s, repo := newTestService(t)
+
+	var revocations []model.Revocation
+	s.OnRevoke(func(r model.Revocation) { revocations = append(revocations, r) })
+
+	laptop := model.Device{Name: "Firefox on Linux", UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/93.0", IP: "192.0.2.1"}
+	first, err := s.GenerateToken(ctx, "alice", "secret", laptop)
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.UserID)
+
+	second, err := s.GenerateToken(ctx, "alice", "secret", model.Device{Name: "phone", IP: "192.0.2.2"})
+	require.NoError(t, err)
+
+	_, err = s.RefreshToken(ctx, first.RefreshToken, model.Device{IP: "192.0.2.3"})
+	require.NoError(t, err)
+
+	sessions, err := s.GetSessions(1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	touches := repo.touches
+	identity, err := s.ParseToken(ctx, second.AccessToken)
+	require.NoError(t, err)
+	_, err = s.ParseToken(ctx, second.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, touches+1, repo.touches, "use is recorded once a minute")
+
+	for _, session := range sessions {
+		if session.ID != identity.SessionID {
+			assert.Equal(t, laptop.Name, session.Name)
+			assert.Equal(t, laptop.UserAgent, session.UserAgent)
+			assert.Equal(t, "192.0.2.3", session.IP, "refresh updates the address")
+		}
+	}
+
+	assert.ErrorIs(t, s.RevokeSession(2, identity.SessionID), model.ErrSessionNotFound, "sessions of other users can't be revoked")
+	assert.Empty(t, revocations)
+
+	require.NoError(t, s.RevokeSession(1, identity.SessionID))
+	assert.Equal(t, []model.Revocation{{UserID: 1, SessionID: identity.SessionID}}, revocations)
+
+	_, err = s.ParseToken(ctx, second.AccessToken)
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+
+	sessions, err = s.GetSessions(1)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	require.NoError(t, s.LogoutAll(1))
+	assert.Equal(t, model.Revocation{UserID: 1}, revocations[1])
+}
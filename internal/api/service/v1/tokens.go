@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+)
+
+// RefreshToken exchanges the refresh token for new tokens. Every refresh token can be used once,
+// presenting a used one means it's stolen, so the whole family is revoked.
+func (s *service) RefreshToken(ctx context.Context, refreshToken string, device model.Device) (model.Tokens, error) {
+	hash := hashToken(refreshToken)
+
+	token, err := s.db.GetRefreshToken(hash)
+	if errors.Is(err, model.ErrTokenNotFound) {
+		return model.Tokens{}, model.ErrInvalidToken
+	}
+
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	if token.RevokedAt != nil || !time.Now().Before(token.ExpiresAt) {
+		return model.Tokens{}, model.ErrInvalidToken
+	}
+
+	err = s.db.UseRefreshToken(hash)
+	if token.UsedAt != nil || errors.Is(err, model.ErrTokenNotFound) {
+		requestid.Log(ctx).Warnf("Refresh token of user %d reused, revoking session %s", token.UserID, token.Family)
+
+		if err := s.db.RevokeTokenFamily(token.Family); err != nil {
+			return model.Tokens{}, err
+		}
+
+		s.revoked(model.Revocation{UserID: token.UserID, SessionID: token.Family})
+
+		return model.Tokens{}, model.ErrInvalidToken
+	}
+
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	s.touch(ctx, token.Family, device.IP)
+
+	return s.issue(token.UserID, token.Family)
+}
+
+// Logout revokes the session
+func (s *service) Logout(sessionID string) error {
+	if err := s.db.RevokeTokenFamily(sessionID); err != nil {
+		return err
+	}
+
+	s.revoked(model.Revocation{SessionID: sessionID})
+
+	return nil
+}
+
+// LogoutAll revokes all sessions of the user
+func (s *service) LogoutAll(userID int) error {
+	if err := s.db.RevokeUserTokens(userID); err != nil {
+		return err
+	}
+
+	s.revoked(model.Revocation{UserID: userID})
+
+	return nil
+}
+
+// issue makes an access token and the next refresh token of the family,
+// roles are read on every refresh, so role changes apply within access token lifetime
+func (s *service) issue(userID int, family string) (model.Tokens, error) {
+	userRoles, err := s.db.GetUserRoles(userID)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	roles, permissions := model.RolePermissions(userRoles)
+
+	refreshToken, err := randomString(32)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	now := time.Now()
+
+	if err := s.db.CreateRefreshToken(model.RefreshToken{
+		UserID:    userID,
+		Family:    family,
+		Hash:      hashToken(refreshToken),
+		ExpiresAt: now.Add(s.tokens.RefreshTTL()),
+	}); err != nil {
+		return model.Tokens{}, err
+	}
+
+	accessToken, err := s.tokens.Sign(&tokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: now.Add(s.tokens.TTL()).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		UserID:      userID,
+		SessionID:   family,
+		Roles:       roles,
+		Permissions: permissions,
+	})
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	return model.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.tokens.TTL().Seconds()),
+		UserID:       userID,
+	}, nil
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is enough for random tokens, they don't need slow hashing like passwords
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,254 @@
+package v1
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository"
+	"github.com/dmytro-vovk/tro/internal/api/service/keys"
+	"github.com/dmytro-vovk/tro/internal/api/service/password"
+	"github.com/dmytro-vovk/tro/internal/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memRepository keeps users, their roles, tokens and TOTP secrets in memory, other methods panic
+type memRepository struct {
+	repository.Repository
+	mutex      sync.Mutex
+	users      []model.User
+	roles      map[int][]model.Role
+	tokens     map[string]*model.RefreshToken
+	totp       map[int]*model.TOTP
+	recovery   map[int]map[string]bool     // unused recovery code hashes
+	userTokens map[string]*model.UserToken // unused tokens by hash
+	apiKeys    []*model.APIKey             // by id, nil once revoked
+	sessions   map[string]*model.Session
+	touches    int // of sessions
+}
+
+func (m *memRepository) GetUserRoles(userID int) ([]model.Role, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.roles[userID], nil
+}
+
+func (m *memRepository) CreateUser(user model.User) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	user.ID = len(m.users) + 1
+	m.users = append(m.users, user)
+
+	return user.ID, nil
+}
+
+func (m *memRepository) GetUserByUsername(username string) (model.User, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, u := range m.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+
+	return model.User{}, model.ErrUserNotFound
+}
+
+func (m *memRepository) CreateRefreshToken(token model.RefreshToken) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.tokens[token.Hash] = &token
+
+	return nil
+}
+
+func (m *memRepository) GetRefreshToken(hash string) (model.RefreshToken, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if t, ok := m.tokens[hash]; ok {
+		return *t, nil
+	}
+
+	return model.RefreshToken{}, model.ErrTokenNotFound
+}
+
+func (m *memRepository) UseRefreshToken(hash string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	t, ok := m.tokens[hash]
+	if !ok || t.UsedAt != nil || t.RevokedAt != nil {
+		return model.ErrTokenNotFound
+	}
+
+	now := time.Now()
+	t.UsedAt = &now
+
+	return nil
+}
+
+func (m *memRepository) revoke(match func(*model.RefreshToken) bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for _, t := range m.tokens {
+		if match(t) && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+}
+
+func (m *memRepository) RevokeTokenFamily(family string) error {
+	m.revoke(func(t *model.RefreshToken) bool { return t.Family == family })
+
+	return nil
+}
+
+func (m *memRepository) RevokeUserTokens(userID int) error {
+	m.revoke(func(t *model.RefreshToken) bool { return t.UserID == userID })
+
+	return nil
+}
+
+func (m *memRepository) TokenFamilyActive(family string) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, t := range m.tokens {
+		if t.Family == family && t.RevokedAt == nil && time.Now().Before(t.ExpiresAt) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func newTestService(t *testing.T) (*service, *memRepository) {
+	ctx := context.Background()
+
+	passwords, err := password.New("bcrypt")
+	require.NoError(t, err)
+
+	tokens, err := keys.New(keys.Config{Secret: "secret"})
+	require.NoError(t, err)
+
+	repo := &memRepository{
+		roles:      make(map[int][]model.Role),
+		tokens:     make(map[string]*model.RefreshToken),
+		totp:       make(map[int]*model.TOTP),
+		recovery:   make(map[int]map[string]bool),
+		userTokens: make(map[string]*model.UserToken),
+		sessions:   make(map[string]*model.Session),
+	}
+	s := New(repo, passwords, tokens, mailer.NewLog("tro@example.com"), Config{PublicURL: "https://tro.example.com/"})
+
+	_, err = s.CreateUser(ctx, model.User{Name: "Alice", Username: "alice", Password: "secret"})
+	require.NoError(t, err)
+
+	return s, repo
+}
+
+func TestRefreshToken(t *testing.T) {
+	ctx := context.Background()
+
+	s, _ := newTestService(t)
+
+	_, err := s.GenerateToken(ctx, "alice", "wrong", model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+
+	first, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+
+	identity, err := s.ParseToken(ctx, first.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, identity.UserID)
+
+	second, err := s.RefreshToken(ctx, first.RefreshToken, model.Device{})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	refreshed, err := s.ParseToken(ctx, second.AccessToken)
+	require.NoError(t, err)
+	assert.False(t, refreshed.ExpiresAt.Before(identity.ExpiresAt))
+	refreshed.ExpiresAt = identity.ExpiresAt // the new token may expire a second later
+	assert.Equal(t, identity, refreshed, "refreshing keeps the session")
+
+	// the first token is stolen and replayed
+	_, err = s.RefreshToken(ctx, first.RefreshToken, model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+
+	_, err = s.RefreshToken(ctx, second.RefreshToken, model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidToken, "reuse revokes the whole family")
+
+	_, err = s.ParseToken(ctx, second.AccessToken)
+	assert.ErrorIs(t, err, model.ErrInvalidToken, "access tokens of revoked sessions are rejected")
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+
+	s, _ := newTestService(t)
+
+	first, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+
+	second, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+
+	identity, err := s.ParseToken(ctx, first.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, s.Logout(identity.SessionID))
+
+	_, err = s.ParseToken(ctx, first.AccessToken)
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+
+	_, err = s.ParseToken(ctx, second.AccessToken)
+	assert.NoError(t, err, "other sessions stay")
+
+	require.NoError(t, s.LogoutAll(identity.UserID))
+
+	_, err = s.ParseToken(ctx, second.AccessToken)
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+
+	_, err = s.RefreshToken(ctx, second.RefreshToken, model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+}
+
+func TestTokenRoles(t *testing.T) {
+	ctx := context.Background()
+
+	s, repo := newTestService(t)
+
+	repo.roles[1] = []model.Role{
+		{Name: "operator", Permissions: []model.Permission{model.PermOperatorsRead, model.PermOperatorsWrite}},
+		{Name: "viewer", Permissions: []model.Permission{model.PermUsersRead, model.PermOperatorsRead}},
+	}
+
+	tokens, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+
+	identity, err := s.ParseToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"operator", "viewer"}, identity.Roles)
+	assert.Equal(t, []model.Permission{model.PermOperatorsRead, model.PermOperatorsWrite, model.PermUsersRead}, identity.Permissions)
+	assert.True(t, identity.Can(model.PermOperatorsWrite, model.PermUsersRead))
+	assert.False(t, identity.Can(model.PermUsersWrite))
+
+	repo.roles[1] = nil
+
+	tokens, err = s.RefreshToken(ctx, tokens.RefreshToken, model.Device{})
+	require.NoError(t, err)
+
+	identity, err = s.ParseToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.False(t, identity.Can(model.PermOperatorsRead), "roles are reread on refresh")
+}
@@ -0,0 +1,205 @@
+package v1
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/service/totp"
+)
+
+const (
+	recoveryCodes    = 10
+	challengeTTL     = 5 * time.Minute
+	challengeSubject = "mfa" // distinguishes MFA tokens from access tokens, which have no subject
+)
+
+var recoveryEncoding = base32.NewEncoding("abcdefghijkmnpqrstuvwxyz23456789").WithPadding(base32.NoPadding)
+
+type challengeClaims struct {
+	jwt.StandardClaims
+	UserID int `json:"user_id"`
+}
+
+// EnrolTOTP makes a new secret, it's pending until activated with a code from the app
+func (s *service) EnrolTOTP(userID int) (model.TOTPEnrolment, error) {
+	if enabled, err := s.totpEnabled(userID); err != nil {
+		return model.TOTPEnrolment{}, err
+	} else if enabled {
+		return model.TOTPEnrolment{}, model.ErrTOTPEnabled
+	}
+
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return model.TOTPEnrolment{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.TOTPEnrolment{}, err
+	}
+
+	if err := s.db.SaveTOTP(userID, secret); err != nil {
+		return model.TOTPEnrolment{}, err
+	}
+
+	return model.TOTPEnrolment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+func (s *service) ActivateTOTP(userID int, code string) ([]string, error) {
+	secret, err := s.db.GetTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret.EnabledAt != nil {
+		return nil, model.ErrTOTPEnabled
+	}
+
+	if err := s.verifyTOTP(secret, code); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodes)
+	hashes := make([]string, recoveryCodes)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		c := recoveryEncoding.EncodeToString(b)
+		codes[i], hashes[i] = c[:4]+"-"+c[4:], hashToken(c)
+	}
+
+	if err := s.db.EnableTOTP(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP removes the secret, pending enrolments are removed without a code
+func (s *service) DisableTOTP(userID int, code string) error {
+	secret, err := s.db.GetTOTP(userID)
+	if err != nil {
+		return err
+	}
+
+	if secret.EnabledAt != nil {
+		if err := s.verifySecondFactor(secret, code); err != nil {
+			return err
+		}
+	}
+
+	return s.db.DeleteTOTP(userID)
+}
+
+func (s *service) SignInTOTP(mfaToken, code string, device model.Device) (model.Tokens, error) {
+	userID, err := s.challengedUserID(mfaToken)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	secret, err := s.db.GetTOTP(userID)
+	if errors.Is(err, model.ErrTOTPNotEnrolled) || err == nil && secret.EnabledAt == nil {
+		return model.Tokens{}, model.ErrInvalidToken
+	}
+
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	if err := s.verifySecondFactor(secret, code); err != nil {
+		return model.Tokens{}, err
+	}
+
+	return s.start(userID, device)
+}
+
+func (s *service) ChallengedUser(mfaToken string) (model.User, error) {
+	userID, err := s.challengedUserID(mfaToken)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	user, err := s.db.GetUserByID(userID)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return model.User{}, model.ErrInvalidToken
+	}
+
+	return user, err
+}
+
+func (s *service) challengedUserID(mfaToken string) (int, error) {
+	token, err := jwt.ParseWithClaims(mfaToken, &challengeClaims{}, s.tokens.Keyfunc)
+	if err != nil {
+		return 0, model.ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*challengeClaims)
+	if !ok || claims.Subject != challengeSubject {
+		return 0, model.ErrInvalidToken
+	}
+
+	return claims.UserID, nil
+}
+
+func (s *service) totpEnabled(userID int) (bool, error) {
+	secret, err := s.db.GetTOTP(userID)
+	if errors.Is(err, model.ErrTOTPNotEnrolled) {
+		return false, nil
+	}
+
+	return err == nil && secret.EnabledAt != nil, err
+}
+
+// challenge makes short-lived MFA token, which proves the password is verified
+func (s *service) challenge(userID int) (model.Tokens, error) {
+	now := time.Now()
+
+	token, err := s.tokens.Sign(&challengeClaims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   challengeSubject,
+			ExpiresAt: now.Add(challengeTTL).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		UserID: userID,
+	})
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	return model.Tokens{MFAToken: token}, nil
+}
+
+// verifySecondFactor accepts a TOTP code or an unused recovery code
+func (s *service) verifySecondFactor(secret model.TOTP, code string) error {
+	code = strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+	if len(code) == totp.Digits {
+		return s.verifyTOTP(secret, code)
+	}
+
+	return s.db.UseRecoveryCode(secret.UserID, hashToken(code))
+}
+
+// verifyTOTP accepts every code once
+func (s *service) verifyTOTP(secret model.TOTP, code string) error {
+	step, ok, err := totp.Validate(secret.Secret, code, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return model.ErrInvalidCode
+	}
+
+	return s.db.UseTOTPStep(secret.UserID, step)
+}
@@ -0,0 +1,178 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/service/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *memRepository) GetUserByID(id int) (model.User, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if id < 1 || id > len(m.users) {
+		return model.User{}, model.ErrUserNotFound
+	}
+
+	return m.users[id-1], nil
+}
+
+func (m *memRepository) GetTOTP(userID int) (model.TOTP, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if t, ok := m.totp[userID]; ok {
+		return *t, nil
+	}
+
+	return model.TOTP{}, model.ErrTOTPNotEnrolled
+}
+
+func (m *memRepository) SaveTOTP(userID int, secret string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.totp[userID] = &model.TOTP{UserID: userID, Secret: secret}
+
+	return nil
+}
+
+func (m *memRepository) EnableTOTP(userID int, recoveryCodeHashes []string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	t, ok := m.totp[userID]
+	if !ok {
+		return model.ErrTOTPNotEnrolled
+	}
+
+	now := time.Now()
+	t.EnabledAt = &now
+
+	m.recovery[userID] = make(map[string]bool)
+	for _, hash := range recoveryCodeHashes {
+		m.recovery[userID][hash] = true
+	}
+
+	return nil
+}
+
+func (m *memRepository) DeleteTOTP(userID int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.totp, userID)
+	delete(m.recovery, userID)
+
+	return nil
+}
+
+func (m *memRepository) UseTOTPStep(userID int, step int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	t, ok := m.totp[userID]
+	if !ok || t.LastStep >= step {
+		return model.ErrInvalidCode
+	}
+
+	t.LastStep = step
+
+	return nil
+}
+
+func (m *memRepository) UseRecoveryCode(userID int, hash string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.recovery[userID][hash] {
+		return model.ErrInvalidCode
+	}
+
+	delete(m.recovery[userID], hash)
+
+	return nil
+}
+
+// stepCode returns the code of a step after the current one, so it's not used yet
+func stepCode(t *testing.T, secret string, steps int) string {
+	code, err := totp.Code(secret, time.Now().Add(time.Duration(steps)*totp.Period))
+	require.NoError(t, err)
+
+	return code
+}
+
+func TestTOTP(t *testing.T) {
+	ctx := context.Background()
+
+	s, _ := newTestService(t)
+
+	enrolment, err := s.EnrolTOTP(1)
+	require.NoError(t, err)
+	assert.Contains(t, enrolment.URI, "otpauth://totp/TRO:alice?")
+
+	tokens, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken, "pending enrolment doesn't require a second factor")
+
+	_, err = s.ActivateTOTP(1, "000000")
+	assert.ErrorIs(t, err, model.ErrInvalidCode)
+
+	codes, err := s.ActivateTOTP(1, stepCode(t, enrolment.Secret, -1))
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodes)
+
+	_, err = s.EnrolTOTP(1)
+	assert.ErrorIs(t, err, model.ErrTOTPEnabled)
+
+	challenge, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+	assert.Empty(t, challenge.AccessToken)
+	require.NotEmpty(t, challenge.MFAToken)
+
+	_, err = s.ParseToken(ctx, challenge.MFAToken)
+	assert.ErrorIs(t, err, model.ErrInvalidToken, "MFA token isn't an access token")
+
+	_, err = s.SignInTOTP(tokens.AccessToken, stepCode(t, enrolment.Secret, 0), model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidToken, "access token isn't an MFA token")
+
+	challenged, err := s.ChallengedUser(challenge.MFAToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", challenged.Username)
+
+	_, err = s.ChallengedUser(tokens.AccessToken)
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+
+	_, err = s.SignInTOTP(challenge.MFAToken, stepCode(t, enrolment.Secret, -1), model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidCode, "codes are accepted once")
+
+	code := stepCode(t, enrolment.Secret, 0)
+
+	tokens, err = s.SignInTOTP(challenge.MFAToken, code, model.Device{})
+	require.NoError(t, err)
+
+	identity, err := s.ParseToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, identity.UserID)
+
+	_, err = s.SignInTOTP(challenge.MFAToken, code, model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidCode)
+
+	_, err = s.SignInTOTP(challenge.MFAToken, codes[0], model.Device{})
+	require.NoError(t, err, "recovery codes replace TOTP codes")
+
+	_, err = s.SignInTOTP(challenge.MFAToken, codes[0], model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidCode, "recovery codes are used once")
+
+	assert.ErrorIs(t, s.DisableTOTP(1, "nonsense"), model.ErrInvalidCode)
+	require.NoError(t, s.DisableTOTP(1, codes[1]))
+
+	tokens, err = s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+}
@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+)
+
+func (s *service) GetUsers(filter model.UserFilter) ([]model.User, int, error) {
+	return s.db.GetUsers(filter)
+}
+
+func (s *service) GetUserByID(id int) (model.User, error) {
+	return s.db.GetUserByID(id)
+}
+
+func (s *service) UpdateUser(ctx context.Context, id int, update model.UserUpdate) error {
+	if update.Password != nil {
+		hash, err := s.passwords.Hash(*update.Password)
+		if err != nil {
+			return err
+		}
+
+		update.Password = &hash
+	}
+
+	if err := s.db.UpdateUser(id, update); err != nil {
+		return err
+	}
+
+	if update.Email != nil && *update.Email != "" {
+		s.sendVerification(ctx, id, *update.Email)
+	}
+
+	return nil
+}
+
+func (s *service) DeleteUser(id int) error {
+	return s.db.DeleteUser(id)
+}
@@ -0,0 +1,17 @@
+package v2
+
+import "github.com/dmytro-vovk/tro/internal/api/model"
+
+// Programs authenticate with the identity provider's client credentials
+
+func (s *service) CreateAPIKey(int, string, []model.Permission) (model.APIKey, string, error) {
+	return model.APIKey{}, "", model.ErrUnsupported
+}
+
+func (s *service) GetAPIKeys(int) ([]model.APIKey, error) {
+	return nil, model.ErrUnsupported
+}
+
+func (s *service) RevokeAPIKey(int, int) error {
+	return model.ErrUnsupported
+}
@@ -1,17 +1,174 @@
 package v2
 
 import (
+	"context"
+	"errors"
+
 	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/service/keys"
+	"github.com/dmytro-vovk/tro/internal/requestid"
 )
 
-func (s *service) CreateUser(user model.User) (int, error) {
-	return 0, nil
+// externalPassword isn't a valid hash, so local passwords of provisioned users can't be used
+const externalPassword = "!external"
+
+// CreateUser is refused, users come from the identity provider
+func (s *service) CreateUser(context.Context, model.User) (int, error) {
+	return 0, model.ErrSignUpDisabled
+}
+
+// GenerateToken signs in at the identity provider and provisions the user
+func (s *service) GenerateToken(ctx context.Context, username, password string, _ model.Device) (model.Tokens, error) {
+	if s.idp == nil {
+		return model.Tokens{}, model.ErrAuthNotConfigured
+	}
+
+	tokens, err := s.idp.token(map[string]string{
+		"grant_type": "password",
+		"username":   username,
+		"password":   password,
+	})
+	if errors.Is(err, errRejected) {
+		return model.Tokens{}, model.ErrInvalidCredentials
+	}
+
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	identity, err := s.ParseToken(ctx, tokens.AccessToken)
+	if err != nil {
+		return model.Tokens{}, err
+	}
+
+	tokens.UserID = identity.UserID
+
+	return tokens, nil
+}
+
+func (s *service) RefreshToken(_ context.Context, refreshToken string, _ model.Device) (model.Tokens, error) {
+	if s.idp == nil {
+		return model.Tokens{}, model.ErrAuthNotConfigured
+	}
+
+	tokens, err := s.idp.token(map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+	})
+	if errors.Is(err, errRejected) {
+		return model.Tokens{}, model.ErrInvalidToken
+	}
+
+	return tokens, err
 }
 
-func (s *service) GenerateToken(username, password string) (string, error) {
-	return "", nil
+func (s *service) Logout(sessionID string) error {
+	if s.idp == nil {
+		return model.ErrAuthNotConfigured
+	}
+
+	if err := s.idp.revoke(map[string]string{"sid": sessionID}); err != nil {
+		return err
+	}
+
+	s.revoked(model.Revocation{SessionID: sessionID})
+
+	return nil
 }
 
-func (s *service) ParseToken(accessToken string) (int, error) {
-	return 0, nil
+func (s *service) LogoutAll(userID int) error {
+	if s.idp == nil {
+		return model.ErrAuthNotConfigured
+	}
+
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.idp.revoke(map[string]string{"username": user.Username}); err != nil {
+		return err
+	}
+
+	s.revoked(model.Revocation{UserID: userID})
+
+	return nil
+}
+
+// ParseToken asks the identity provider whose token it is, the owner is provisioned if it's new
+func (s *service) ParseToken(ctx context.Context, accessToken string) (model.Identity, error) {
+	if s.idp == nil {
+		return model.Identity{}, model.ErrAuthNotConfigured
+	}
+
+	info, err := s.idp.userInfo(accessToken)
+	if errors.Is(err, errRejected) {
+		return model.Identity{}, model.ErrInvalidToken
+	}
+
+	if err != nil {
+		return model.Identity{}, err
+	}
+
+	user, err := s.provision(ctx, info)
+	if err != nil {
+		return model.Identity{}, err
+	}
+
+	userRoles, err := s.db.GetUserRoles(user.ID)
+	if err != nil {
+		return model.Identity{}, err
+	}
+
+	roles, permissions := model.RolePermissions(userRoles)
+
+	return model.Identity{
+		UserID:      user.ID,
+		SessionID:   info.SessionID,
+		Roles:       roles,
+		Permissions: permissions,
+	}, nil
+}
+
+// JWKS is empty, tokens are issued by the identity provider
+func (s *service) JWKS() keys.JWKS {
+	return keys.JWKS{Keys: []keys.JWK{}}
+}
+
+// provision returns the local user of the subject, creating one with default roles if it doesn't exist,
+// local users with the same username are never taken over
+func (s *service) provision(ctx context.Context, info userInfo) (model.User, error) {
+	user, err := s.db.GetUserBySubject(info.Subject)
+	if err == nil || !errors.Is(err, model.ErrUserNotFound) {
+		return user, err
+	}
+
+	name := info.Name
+	if name == "" {
+		name = info.Username
+	}
+
+	user = model.User{Name: name, Username: info.Username, Password: externalPassword, Subject: info.Subject}
+	if user.ID, err = s.db.CreateUser(user); errors.Is(err, model.ErrUsernameTaken) {
+		// provisioned by a concurrent request, or the username is of a local user
+		if user, err = s.db.GetUserBySubject(info.Subject); errors.Is(err, model.ErrUserNotFound) {
+			requestid.Log(ctx).Printf("Refused to provision user %q from identity provider: the username is taken", info.Username)
+
+			return model.User{}, model.ErrLocalUser
+		}
+
+		return user, err
+	} else if err != nil {
+		return model.User{}, err
+	}
+
+	if len(s.defaultRoles) > 0 {
+		if err := s.db.SetUserRoles(user.ID, s.defaultRoles); err != nil {
+			return model.User{}, err
+		}
+	}
+
+	requestid.Log(ctx).Printf("Provisioned user %q from identity provider", info.Username)
+
+	return user, nil
 }
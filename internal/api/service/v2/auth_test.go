@@ -0,0 +1,179 @@
+package v2
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository"
+	"github.com/dmytro-vovk/tro/internal/api/service/v2/idptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memRepository keeps users and their roles in memory, other methods panic
+type memRepository struct {
+	repository.Repository
+	mutex sync.Mutex
+	users []model.User
+	roles map[int][]model.Role
+}
+
+func (m *memRepository) CreateUser(user model.User) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, u := range m.users {
+		if u.Username == user.Username {
+			return 0, model.ErrUsernameTaken
+		}
+	}
+
+	user.ID = len(m.users) + 1
+	m.users = append(m.users, user)
+
+	return user.ID, nil
+}
+
+func (m *memRepository) GetUserByUsername(username string) (model.User, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, u := range m.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+
+	return model.User{}, model.ErrUserNotFound
+}
+
+func (m *memRepository) GetUserBySubject(subject string) (model.User, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, u := range m.users {
+		if u.Subject != "" && u.Subject == subject {
+			return u, nil
+		}
+	}
+
+	return model.User{}, model.ErrUserNotFound
+}
+
+func (m *memRepository) GetUserByID(id int) (model.User, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if id < 1 || id > len(m.users) {
+		return model.User{}, model.ErrUserNotFound
+	}
+
+	return m.users[id-1], nil
+}
+
+func (m *memRepository) GetUserRoles(userID int) ([]model.Role, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.roles[userID], nil
+}
+
+func (m *memRepository) SetUserRoles(userID int, roles []string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.roles[userID] = nil
+	for _, name := range roles {
+		m.roles[userID] = append(m.roles[userID], model.Role{
+			Name:        name,
+			Permissions: []model.Permission{model.PermOperatorsRead},
+		})
+	}
+
+	return nil
+}
+
+func TestNotConfigured(t *testing.T) {
+	ctx := context.Background()
+
+	s := New(&memRepository{roles: map[int][]model.Role{}}, Config{})
+
+	_, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	assert.ErrorIs(t, err, model.ErrAuthNotConfigured)
+
+	_, err = s.RefreshToken(ctx, "token", model.Device{})
+	assert.ErrorIs(t, err, model.ErrAuthNotConfigured)
+
+	_, err = s.ParseToken(ctx, "token")
+	assert.ErrorIs(t, err, model.ErrAuthNotConfigured)
+
+	_, err = s.CreateUser(ctx, model.User{Username: "alice", Password: "secret"})
+	assert.ErrorIs(t, err, model.ErrSignUpDisabled)
+}
+
+func TestIdentityProvider(t *testing.T) {
+	ctx := context.Background()
+
+	provider := idptest.NewServer(t, idptest.User{Username: "alice", Password: "secret", Name: "Alice"})
+	repo := &memRepository{roles: map[int][]model.Role{}}
+	s := New(repo, Config{
+		URL:          provider.URL,
+		ClientID:     idptest.ClientID,
+		ClientSecret: idptest.ClientSecret,
+		DefaultRoles: []string{"viewer"},
+	})
+
+	_, err := s.GenerateToken(ctx, "alice", "wrong", model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidCredentials)
+	assert.Empty(t, repo.users, "users are provisioned on successful login only")
+
+	tokens, err := s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+	assert.Equal(t, idptest.ExpiresIn, tokens.ExpiresIn)
+
+	require.Len(t, repo.users, 1)
+	assert.Equal(t, "Alice", repo.users[0].Name)
+	assert.Equal(t, externalPassword, repo.users[0].Password)
+	assert.Equal(t, "sub-alice", repo.users[0].Subject)
+
+	identity, err := s.ParseToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, identity.UserID)
+	assert.Equal(t, []string{"viewer"}, identity.Roles)
+	assert.True(t, identity.Can(model.PermOperatorsRead))
+
+	_, err = s.GenerateToken(ctx, "alice", "secret", model.Device{})
+	require.NoError(t, err)
+	assert.Len(t, repo.users, 1, "users are provisioned once")
+	assert.Equal(t, 2, provider.Sessions("alice"))
+
+	refreshed, err := s.RefreshToken(ctx, tokens.RefreshToken, model.Device{})
+	require.NoError(t, err)
+
+	_, err = s.RefreshToken(ctx, tokens.RefreshToken, model.Device{})
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+
+	require.NoError(t, s.Logout(identity.SessionID))
+
+	_, err = s.ParseToken(ctx, refreshed.AccessToken)
+	assert.ErrorIs(t, err, model.ErrInvalidToken)
+	assert.Equal(t, 1, provider.Sessions("alice"))
+
+	require.NoError(t, s.LogoutAll(identity.UserID))
+	assert.Zero(t, provider.Sessions("alice"))
+}
+
+func TestLocalUserNotTakenOver(t *testing.T) {
+	ctx := context.Background()
+
+	provider := idptest.NewServer(t, idptest.User{Username: "admin", Password: "secret"})
+	repo := &memRepository{roles: map[int][]model.Role{}}
+	repo.users = []model.User{{ID: 1, Username: "admin", Password: "local"}}
+	s := New(repo, Config{URL: provider.URL, ClientID: idptest.ClientID, ClientSecret: idptest.ClientSecret})
+
+	_, err := s.GenerateToken(ctx, "admin", "secret", model.Device{})
+	assert.ErrorIs(t, err, model.ErrLocalUser)
+	assert.Len(t, repo.users, 1)
+}
@@ -0,0 +1,114 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+)
+
+// errRejected means the provider refused credentials or a token
+var errRejected = errors.New("rejected by identity provider")
+
+// idp is a client of the identity API:
+//
+//	POST /token     {"grant_type": "password", "username", "password"} or {"grant_type": "refresh_token", "refresh_token"}
+//	GET  /userinfo  with the access token as Bearer authorization
+//	POST /revoke    {"sid"} ends a session, {"username"} ends all sessions of the user
+//
+// token and revoke requests are authorized by client credentials.
+type idp struct {
+	url          string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+}
+
+// userInfo is the owner of an access token
+type userInfo struct {
+	Subject   string `json:"sub"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	SessionID string `json:"sid"`
+}
+
+func (p *idp) token(grant map[string]string) (model.Tokens, error) {
+	var tokens model.Tokens
+	if err := p.do(http.MethodPost, "/token", "", grant, &tokens); err != nil {
+		return model.Tokens{}, err
+	}
+
+	return tokens, nil
+}
+
+func (p *idp) userInfo(accessToken string) (userInfo, error) {
+	var info userInfo
+	if err := p.do(http.MethodGet, "/userinfo", accessToken, nil, &info); err != nil {
+		return userInfo{}, err
+	}
+
+	if info.Subject == "" || info.Username == "" || info.SessionID == "" {
+		return userInfo{}, errors.New("identity provider returned incomplete user info")
+	}
+
+	return info, nil
+}
+
+func (p *idp) revoke(request map[string]string) error {
+	return p.do(http.MethodPost, "/revoke", "", request, nil)
+}
+
+// do sends the request, bearer token or client credentials authorize it,
+// 400 and 401 responses are reported as errRejected
+func (p *idp) do(method, path, bearer string, body, result interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, p.url+path, payload)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	} else {
+		req.SetBasicAuth(p.clientID, p.clientSecret)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("identity provider request failed: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest, resp.StatusCode == http.StatusUnauthorized:
+		return errRejected
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("identity provider %s %s responded %s", method, path, resp.Status)
+	case result == nil:
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding identity provider response: %w", err)
+	}
+
+	return nil
+}
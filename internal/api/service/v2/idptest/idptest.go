@@ -0,0 +1,190 @@
+// Package idptest provides an in-memory identity provider speaking the API v2 service is a client of
+package idptest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	ClientID     = "test-client"
+	ClientSecret = "test-secret"
+	ExpiresIn    = 300
+)
+
+type User struct {
+	Username string
+	Password string
+	Name     string
+}
+
+type session struct {
+	id       string
+	username string
+}
+
+// Server is an identity provider, it will be closed on test cleanup
+type Server struct {
+	*httptest.Server
+	mutex         sync.Mutex
+	users         map[string]User
+	accessTokens  map[string]session
+	refreshTokens map[string]session
+}
+
+func NewServer(t testing.TB, users ...User) *Server {
+	t.Helper()
+
+	s := &Server{
+		users:         make(map[string]User),
+		accessTokens:  make(map[string]session),
+		refreshTokens: make(map[string]session),
+	}
+
+	for _, u := range users {
+		s.users[u.Username] = u
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", s.clientOnly(s.token))
+	mux.HandleFunc("/userinfo", s.userInfo)
+	mux.HandleFunc("/revoke", s.clientOnly(s.revoke))
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Sessions returns the number of active sessions of the user
+func (s *Server) Sessions(username string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids := make(map[string]bool)
+	for _, session := range s.refreshTokens {
+		if session.username == username {
+			ids[session.id] = true
+		}
+	}
+
+	return len(ids)
+}
+
+func (s *Server) clientOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != ClientID || secret != ClientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var current session
+	switch req["grant_type"] {
+	case "password":
+		user, ok := s.users[req["username"]]
+		if !ok || user.Password != req["password"] {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		current = session{id: random(), username: user.Username}
+	case "refresh_token":
+		var ok bool
+		if current, ok = s.refreshTokens[req["refresh_token"]]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		delete(s.refreshTokens, req["refresh_token"])
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	accessToken, refreshToken := random(), random()
+	s.accessTokens[accessToken] = current
+	s.refreshTokens[refreshToken] = current
+
+	respond(w, map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    ExpiresIn,
+	})
+}
+
+func (s *Server) userInfo(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, ok := s.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	respond(w, map[string]string{
+		"sub":      "sub-" + session.username,
+		"username": session.username,
+		"name":     s.users[session.username].Name,
+		"sid":      session.id,
+	})
+}
+
+// revoke ends sessions by id or all sessions of the username
+func (s *Server) revoke(w http.ResponseWriter, r *http.Request) {
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, tokens := range []map[string]session{s.accessTokens, s.refreshTokens} {
+		for token, session := range tokens {
+			if session.id == req["sid"] || session.username == req["username"] {
+				delete(tokens, token)
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respond(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func random() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
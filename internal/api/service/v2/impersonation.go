@@ -0,0 +1,9 @@
+package v2
+
+import "github.com/dmytro-vovk/tro/internal/api/model"
+
+// Tokens are issued by the identity provider only
+
+func (s *service) Impersonate(model.Identity, int) (model.Tokens, error) {
+	return model.Tokens{}, model.ErrUnsupported
+}
@@ -0,0 +1,21 @@
+package v2
+
+import (
+	"context"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+)
+
+// Passwords and emails are managed by the identity provider
+
+func (s *service) ForgotPassword(context.Context, string) error {
+	return model.ErrUnsupported
+}
+
+func (s *service) ResetPassword(string, string) error {
+	return model.ErrUnsupported
+}
+
+func (s *service) VerifyEmail(string) error {
+	return model.ErrUnsupported
+}
@@ -0,0 +1,15 @@
+package v2
+
+import "github.com/dmytro-vovk/tro/internal/api/model"
+
+func (s *service) GetRoles() ([]model.Role, error) {
+	return s.db.GetRoles()
+}
+
+func (s *service) GetUserRoles(userID int) ([]model.Role, error) {
+	return s.db.GetUserRoles(userID)
+}
+
+func (s *service) SetUserRoles(userID int, roles []string) error {
+	return s.db.SetUserRoles(userID, roles)
+}
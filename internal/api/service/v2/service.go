@@ -1,11 +1,61 @@
+// Package v2 delegates authentication to an external identity provider.
+// Users sign in with the provider's credentials, its tokens are handed to clients as is
+// and checked at the provider on every request. Users are provisioned locally on first login
+// by username, so they get local ids and roles.
 package v2
 
-import "github.com/dmytro-vovk/tro/internal/api/repository"
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultTimeout = 10 * time.Second
+
+type Config struct {
+	URL          string        `mapstructure:"url"` // base URL of the identity API, logins are refused if empty
+	ClientID     string        `mapstructure:"client_id"`
+	ClientSecret string        `mapstructure:"client_secret"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	DefaultRoles []string      `mapstructure:"default_roles"` // roles of users provisioned on first login
+}
 
 type service struct {
-	db repository.Repository
+	db           repository.Repository
+	idp          *idp // nil until configured
+	defaultRoles []string
+	revoke       struct {
+		sync.RWMutex
+		fn func(model.Revocation)
+	}
 }
 
-func New(db repository.Repository) *service {
-	return &service{db: db}
+func New(db repository.Repository, c Config) *service {
+	s := &service{
+		db:           db,
+		defaultRoles: c.DefaultRoles,
+	}
+
+	if c.URL == "" {
+		logrus.Warn("Identity provider URL is not configured, logins will be refused")
+		return s
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = defaultTimeout
+	}
+
+	s.idp = &idp{
+		url:          strings.TrimSuffix(c.URL, "/"),
+		clientID:     c.ClientID,
+		clientSecret: c.ClientSecret,
+		client:       &http.Client{Timeout: c.Timeout},
+	}
+
+	return s
 }
@@ -0,0 +1,29 @@
+package v2
+
+import "github.com/dmytro-vovk/tro/internal/api/model"
+
+// Sessions are kept by the identity provider, they can be ended by logout only
+
+func (s *service) GetSessions(int) ([]model.Session, error) {
+	return nil, model.ErrUnsupported
+}
+
+func (s *service) RevokeSession(int, string) error {
+	return model.ErrUnsupported
+}
+
+func (s *service) OnRevoke(fn func(model.Revocation)) {
+	s.revoke.Lock()
+	s.revoke.fn = fn
+	s.revoke.Unlock()
+}
+
+func (s *service) revoked(r model.Revocation) {
+	s.revoke.RLock()
+	fn := s.revoke.fn
+	s.revoke.RUnlock()
+
+	if fn != nil {
+		fn(r)
+	}
+}
@@ -0,0 +1,25 @@
+package v2
+
+import "github.com/dmytro-vovk/tro/internal/api/model"
+
+// Second factor is enforced by the identity provider
+
+func (s *service) EnrolTOTP(int) (model.TOTPEnrolment, error) {
+	return model.TOTPEnrolment{}, model.ErrUnsupported
+}
+
+func (s *service) ActivateTOTP(int, string) ([]string, error) {
+	return nil, model.ErrUnsupported
+}
+
+func (s *service) DisableTOTP(int, string) error {
+	return model.ErrUnsupported
+}
+
+func (s *service) SignInTOTP(string, string, model.Device) (model.Tokens, error) {
+	return model.Tokens{}, model.ErrUnsupported
+}
+
+func (s *service) ChallengedUser(string) (model.User, error) {
+	return model.User{}, model.ErrUnsupported
+}
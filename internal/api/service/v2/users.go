@@ -0,0 +1,30 @@
+package v2
+
+import (
+	"context"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/errors"
+)
+
+var errPasswordManagedExternally = errors.New(errors.Forbidden, "password is managed by the identity provider")
+
+func (s *service) GetUsers(filter model.UserFilter) ([]model.User, int, error) {
+	return s.db.GetUsers(filter)
+}
+
+func (s *service) GetUserByID(id int) (model.User, error) {
+	return s.db.GetUserByID(id)
+}
+
+func (s *service) UpdateUser(_ context.Context, id int, update model.UserUpdate) error {
+	if update.Password != nil {
+		return errPasswordManagedExternally
+	}
+
+	return s.db.UpdateUser(id, update)
+}
+
+func (s *service) DeleteUser(id int) error {
+	return s.db.DeleteUser(id)
+}
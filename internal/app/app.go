@@ -1,22 +1,22 @@
 package app
 
 import (
-	"github.com/jmoiron/sqlx"
+	"github.com/dmytro-vovk/tro/internal/api/repository"
 )
 
 type Application struct {
 	responseCounter int
 	streamer        Streamer
-	db              *sqlx.DB
+	operators       repository.Operators
 }
 
 type Streamer interface {
 	Notify(string, interface{})
 }
 
-func New(db *sqlx.DB) *Application {
+func New(operators repository.Operators) *Application {
 	return &Application{
-		db: db,
+		operators: operators,
 	}
 }
 
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/errors"
+)
+
+// OperatorsChanged is the topic notified about every change of operators
+const OperatorsChanged = "operators.changed"
+
+const maxLoginLength = 20
+
+var (
+	errEmptyLogin = errors.New(errors.Invalid, "operator login is empty")
+	errLongLogin  = errors.New(errors.Invalid, fmt.Sprintf("operator login is longer than %d characters", maxLoginLength))
+)
+
+type OperatorRequest struct {
+	ID int `json:"id"`
+}
+
+type OperatorCreateRequest struct {
+	Login string `json:"login"`
+}
+
+type OperatorRenameRequest struct {
+	ID    int    `json:"id"`
+	Login string `json:"login"`
+}
+
+type operatorChange struct {
+	Action   string         `json:"action"` // created, renamed, disabled or deleted
+	Operator model.Operator `json:"operator"`
+}
+
+func (a *Application) Operators() ([]model.Operator, error) {
+	operators, err := a.operators.GetOperators()
+	if err != nil {
+		return nil, err
+	}
+
+	return operators, nil
+}
+
+func (a *Application) Operator(r OperatorRequest) (*model.Operator, error) {
+	operator, err := a.operators.GetOperator(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &operator, nil
+}
+
+func (a *Application) OperatorCreate(ctx context.Context, r OperatorCreateRequest) (*model.Operator, error) {
+	login, err := validLogin(r.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := a.operators.CreateOperator(login)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.operatorChanged(ctx, "created", id, nil)
+}
+
+func (a *Application) OperatorRename(ctx context.Context, r OperatorRenameRequest) (*model.Operator, error) {
+	login, err := validLogin(r.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := a.operators.GetOperator(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.operators.RenameOperator(r.ID, login); err != nil {
+		return nil, err
+	}
+
+	return a.operatorChanged(ctx, "renamed", r.ID, before)
+}
+
+func (a *Application) OperatorDisable(ctx context.Context, r OperatorRequest) (*model.Operator, error) {
+	before, err := a.operators.GetOperator(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.operators.DisableOperator(r.ID); err != nil {
+		return nil, err
+	}
+
+	return a.operatorChanged(ctx, "disabled", r.ID, before)
+}
+
+func (a *Application) OperatorDelete(ctx context.Context, r OperatorRequest) error {
+	operator, err := a.operators.GetOperator(r.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := a.operators.DeleteOperator(r.ID); err != nil {
+		return err
+	}
+
+	audit.Change(ctx, operator, nil)
+	a.notify(operatorChange{Action: "deleted", Operator: operator})
+
+	return nil
+}
+
+// operatorChanged notifies subscribers about the operator and returns it, before is nil for a new operator
+func (a *Application) operatorChanged(ctx context.Context, action string, id int, before interface{}) (*model.Operator, error) {
+	operator, err := a.operators.GetOperator(id)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Change(ctx, before, operator)
+
+	a.notify(operatorChange{Action: action, Operator: operator})
+
+	return &operator, nil
+}
+
+func (a *Application) notify(change operatorChange) {
+	if a.streamer != nil {
+		a.streamer.Notify(OperatorsChanged, change)
+	}
+}
+
+func validLogin(login string) (string, error) {
+	login = strings.TrimSpace(login)
+
+	switch n := utf8.RuneCountInString(login); {
+	case n == 0:
+		return "", errEmptyLogin
+	case n > maxLoginLength:
+		return "", errLongLogin
+	}
+
+	return login, nil
+}
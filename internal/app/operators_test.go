@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memOperators struct {
+	operators []model.Operator
+}
+
+func (m *memOperators) GetOperators() ([]model.Operator, error) {
+	return m.operators, nil
+}
+
+func (m *memOperators) GetOperator(id int) (model.Operator, error) {
+	for _, o := range m.operators {
+		if o.ID == id {
+			return o, nil
+		}
+	}
+
+	return model.Operator{}, model.ErrOperatorNotFound
+}
+
+func (m *memOperators) CreateOperator(login string) (int, error) {
+	for _, o := range m.operators {
+		if o.Login == login {
+			return 0, model.ErrOperatorLoginTaken
+		}
+	}
+
+	m.operators = append(m.operators, model.Operator{ID: len(m.operators) + 1, Login: login})
+
+	return len(m.operators), nil
+}
+
+func (m *memOperators) RenameOperator(id int, login string) error {
+	for i := range m.operators {
+		if m.operators[i].ID == id {
+			m.operators[i].Login = login
+			return nil
+		}
+	}
+
+	return model.ErrOperatorNotFound
+}
+
+func (m *memOperators) DisableOperator(id int) error {
+	for i := range m.operators {
+		if m.operators[i].ID == id {
+			m.operators[i].Disabled = true
+			return nil
+		}
+	}
+
+	return model.ErrOperatorNotFound
+}
+
+func (m *memOperators) DeleteOperator(id int) error {
+	for i := range m.operators {
+		if m.operators[i].ID == id {
+			m.operators = append(m.operators[:i], m.operators[i+1:]...)
+			return nil
+		}
+	}
+
+	return model.ErrOperatorNotFound
+}
+
+type recorder struct {
+	changes []operatorChange
+}
+
+func (r *recorder) Notify(method string, data interface{}) {
+	if method == OperatorsChanged {
+		r.changes = append(r.changes, data.(operatorChange))
+	}
+}
+
+func errorCode(t *testing.T, err error) int {
+	require.Error(t, err)
+
+	return jsonrpc.FromError(err).Code
+}
+
+func TestOperators(t *testing.T) {
+	ctx := context.Background()
+	streamer := &recorder{}
+	a := &Application{operators: &memOperators{}, streamer: streamer}
+
+	created, err := a.OperatorCreate(ctx, OperatorCreateRequest{Login: " alice "})
+	require.NoError(t, err)
+	assert.Equal(t, model.Operator{ID: 1, Login: "alice"}, *created)
+
+	_, err = a.OperatorCreate(ctx, OperatorCreateRequest{Login: "alice"})
+	assert.Equal(t, jsonrpc.CodeConflict, errorCode(t, err))
+
+	_, err = a.OperatorCreate(ctx, OperatorCreateRequest{Login: "  "})
+	assert.Equal(t, jsonrpc.CodeInvalidParams, errorCode(t, err))
+
+	renamed, err := a.OperatorRename(ctx, OperatorRenameRequest{ID: 1, Login: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", renamed.Login)
+
+	disabled, err := a.OperatorDisable(ctx, OperatorRequest{ID: 1})
+	require.NoError(t, err)
+	assert.True(t, disabled.Disabled)
+
+	require.NoError(t, a.OperatorDelete(ctx, OperatorRequest{ID: 1}))
+
+	_, err = a.Operator(OperatorRequest{ID: 1})
+	assert.Equal(t, jsonrpc.CodeNotFound, errorCode(t, err))
+
+	actions := make([]string, 0, len(streamer.changes))
+	for _, c := range streamer.changes {
+		actions = append(actions, c.Action)
+	}
+
+	assert.Equal(t, []string{"created", "renamed", "disabled", "deleted"}, actions)
+}
@@ -0,0 +1,121 @@
+// Package audit records important actions to the append-only audit log and streams them to subscribers
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/api/repository"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/sirupsen/logrus"
+)
+
+// Events is the topic notified about every recorded event
+const Events = "audit.events"
+
+type Streamer interface {
+	Notify(string, interface{})
+}
+
+// Log is service.Auditor storing events in the repository
+type Log struct {
+	db       repository.AuditLog
+	streamer Streamer
+	mutex    sync.RWMutex
+}
+
+func New(db repository.AuditLog) *Log {
+	return &Log{db: db}
+}
+
+func (l *Log) SetStreamer(s Streamer) {
+	l.mutex.Lock()
+	l.streamer = s
+	l.mutex.Unlock()
+}
+
+func (l *Log) Audit(event model.AuditEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	// the column is limited in characters, a longer target would fail the insert and lose the event
+	if target := []rune(event.Target); len(target) > model.MaxAuditTarget {
+		event.Target = string(target[:model.MaxAuditTarget])
+	}
+
+	id, err := l.db.CreateAuditEvent(event)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"audit": event.Action, requestid.Field: event.RequestID}).Errorf("Error recording audit event of %s: %s", event.Target, err)
+		return
+	}
+
+	event.ID = id
+
+	l.mutex.RLock()
+	streamer := l.streamer
+	l.mutex.RUnlock()
+
+	if streamer != nil {
+		streamer.Notify(Events, event)
+	}
+}
+
+func (l *Log) GetAuditEvents(filter model.AuditFilter) ([]model.AuditEvent, int, error) {
+	return l.db.GetAuditEvents(filter)
+}
+
+// Diff returns fields of JSON objects before and after which differ, nil stands for absent object.
+// Unchanged objects give no snapshots.
+func Diff(before, after interface{}) (model.Snapshot, model.Snapshot) {
+	b, a := fields(before), fields(after)
+	for k := range b {
+		if v, ok := a[k]; ok && reflect.DeepEqual(v, b[k]) {
+			delete(a, k)
+			delete(b, k)
+		}
+	}
+
+	return snapshot(b), snapshot(a)
+}
+
+// Target makes target of an event, e.g. "users:12"
+func Target(kind string, id interface{}) string {
+	return fmt.Sprintf("%s:%v", kind, id)
+}
+
+func fields(v interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	if v == nil {
+		return m
+	}
+
+	b, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(b, &m)
+	}
+
+	if err != nil {
+		logrus.Errorf("Can't snapshot %T for audit: %s", v, err)
+	}
+
+	return m
+}
+
+func snapshot(m map[string]interface{}) model.Snapshot {
+	if len(m) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		logrus.Errorf("Can't encode audit snapshot: %s", err)
+		return nil
+	}
+
+	return b
+}
@@ -0,0 +1,138 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/rbac"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client/clienttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memLog struct {
+	events []model.AuditEvent
+	mutex  sync.Mutex
+}
+
+func (m *memLog) CreateAuditEvent(event model.AuditEvent) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.events = append(m.events, event)
+
+	return len(m.events), nil
+}
+
+func (m *memLog) GetAuditEvents(model.AuditFilter) ([]model.AuditEvent, int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.events, len(m.events), nil
+}
+
+type recorder []interface{}
+
+func (r *recorder) Notify(method string, data interface{}) {
+	if method == audit.Events {
+		*r = append(*r, data)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type user struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	}
+
+	before, after := audit.Diff(user{Name: "Alice", Username: "alice"}, user{Name: "Alice B", Username: "alice"})
+	assert.JSONEq(t, `{"name": "Alice"}`, string(before))
+	assert.JSONEq(t, `{"name": "Alice B"}`, string(after))
+
+	before, after = audit.Diff(nil, user{Name: "Bob", Username: "bob"})
+	assert.Nil(t, before)
+	assert.JSONEq(t, `{"name": "Bob", "username": "bob"}`, string(after))
+
+	before, after = audit.Diff(user{Name: "Bob"}, user{Name: "Bob"})
+	assert.Nil(t, before)
+	assert.Nil(t, after)
+}
+
+func TestLog(t *testing.T) {
+	db, stream := &memLog{}, &recorder{}
+	log := audit.New(db)
+	log.SetStreamer(stream)
+
+	log.Audit(model.AuditEvent{Action: model.AuditSignIn, Target: audit.Target("users", 1), ActorID: 1})
+
+	events, total, err := log.GetAuditEvents(model.AuditFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.False(t, events[0].Time.IsZero(), "time is set")
+	assert.Equal(t, "users:1", events[0].Target)
+
+	require.Len(t, *stream, 1)
+	assert.Equal(t, 1, (*stream)[0].(model.AuditEvent).ID)
+
+	log.Audit(model.AuditEvent{Action: model.AuditSignInFailed, Target: "username:" + strings.Repeat("ї", 200)})
+	assert.Equal(t, model.MaxAuditTarget, utf8.RuneCountInString(db.events[1].Target), "fits the column")
+}
+
+func TestCalls(t *testing.T) {
+	db := &memLog{}
+	type operator struct {
+		ID    int    `json:"id"`
+		Login string `json:"login"`
+	}
+	type request struct {
+		ID int `json:"id"`
+	}
+
+	c := client.New().
+		Use(audit.Calls(audit.New(db), "operators.create", "operators.delete")).
+		NS("operators",
+			client.NSMethod("create", func() (*operator, error) { return &operator{ID: 3, Login: "bob"}, nil }),
+			client.NSMethod("delete", func(ctx context.Context, r request) error {
+				audit.Change(ctx, operator{ID: r.ID, Login: "alice"}, nil)
+				return nil
+			}),
+			client.NSMethod("rename", func(request) error { return errors.New("not audited") }),
+			client.NSMethod("list", func() ([]operator, error) { return nil, nil }),
+		)
+
+	identity := model.Identity{UserID: 7, ImpersonatorID: 1}
+	server := clienttest.NewServer(t, c, requestid.Handler, audit.Source, func(next http.HandlerFunc) http.HandlerFunc {
+		return rbac.Authenticate(func(context.Context, string) (model.Identity, error) { return identity, nil }, next)
+	})
+
+	conn := server.DialHeader(http.Header{"Authorization": {"Bearer token"}, "X-Request-ID": {"req-1"}})
+	assert.NoError(t, conn.Call("operators.create", nil, nil))
+	assert.NoError(t, conn.Call("operators.delete", request{ID: 4}, nil))
+	assert.NoError(t, conn.Call("operators.list", nil, nil))
+	assert.Error(t, conn.Call("operators.rename", request{ID: 4}, nil))
+
+	require.Len(t, db.events, 2, "only successful calls of listed methods")
+
+	created := db.events[0]
+	assert.Equal(t, "operators.create", created.Action)
+	assert.Equal(t, "operators:3", created.Target)
+	assert.JSONEq(t, `{"id": 3, "login": "bob"}`, string(created.After))
+	assert.Equal(t, 7, created.ActorID)
+	assert.Equal(t, 1, created.ImpersonatorID)
+	assert.Equal(t, "127.0.0.1", created.IP)
+	assert.Equal(t, "req-1-1", created.RequestID, "calls get ids derived from the connection's one")
+
+	assert.Equal(t, "operators:4", db.events[1].Target, "target from params")
+	assert.Equal(t, "req-1-2", db.events[1].RequestID)
+	assert.JSONEq(t, `{"id": 4, "login": "alice"}`, string(db.events[1].Before), "reported by the method")
+	assert.Nil(t, db.events[1].After)
+}
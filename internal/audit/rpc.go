@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/dmytro-vovk/tro/internal/rbac"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
+)
+
+type (
+	sourceKey struct{}
+	changeKey struct{}
+)
+
+// change is the state of the call's target reported by the method
+type change struct {
+	before, after interface{}
+	reported      bool
+}
+
+type Recorder interface {
+	Audit(event model.AuditEvent)
+}
+
+// Source puts the client address into the request context, so events of RPC calls made over the connection have it,
+// request ids are taken from the context as well
+func Source(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), sourceKey{}, ip)))
+	}
+}
+
+// NewEvent makes event of the caller
+func NewEvent(ctx context.Context, action, target string) model.AuditEvent {
+	event := model.AuditEvent{
+		Action:    action,
+		Target:    target,
+		RequestID: requestid.From(ctx),
+	}
+
+	if identity, ok := rbac.IdentityFrom(ctx); ok {
+		event.ActorID, event.ImpersonatorID = identity.UserID, identity.ImpersonatorID
+	}
+
+	if ip, ok := ctx.Value(sourceKey{}).(string); ok {
+		event.IP = ip
+	}
+
+	return event
+}
+
+// Change reports the state of the target before and after the call to Calls, nil stands for absent object,
+// it does nothing if the call isn't audited
+func Change(ctx context.Context, before, after interface{}) {
+	if c, ok := ctx.Value(changeKey{}).(*change); ok {
+		c.before, c.after, c.reported = before, after, true
+	}
+}
+
+// Calls records successful calls of the methods. The target is the namespace and "id" of the result
+// or of params, e.g. "operators:3". Changed fields are those reported by Change, or the result is the state
+// after the call if the method reports nothing.
+func Calls(r Recorder, methods ...string) client.Middleware {
+	audited := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		audited[m] = true
+	}
+
+	return func(next client.HandlerFunc) client.HandlerFunc {
+		return func(ctx context.Context, req jsonrpc.Request) jsonrpc.Response {
+			if !audited[req.Method] {
+				return next(ctx, req)
+			}
+
+			c := new(change)
+			resp := next(context.WithValue(ctx, changeKey{}, c), req)
+			if resp.Error != nil {
+				return resp
+			}
+
+			target := req.Method
+			if i := strings.Index(target, "."); i > 0 {
+				target = target[:i]
+			}
+
+			if id, ok := objectID(resp.Result); ok {
+				target = Target(target, id)
+			} else if id, ok := objectID(req.Params); ok {
+				target = Target(target, id)
+			}
+
+			event := NewEvent(ctx, req.Method, target)
+			if c.reported {
+				event.Before, event.After = Diff(c.before, c.after)
+			} else if len(resp.Result) > 0 && string(resp.Result) != "null" {
+				event.After = model.Snapshot(resp.Result)
+			}
+
+			r.Audit(event)
+
+			return resp
+		}
+	}
+}
+
+func objectID(payload json.RawMessage) (json.Number, bool) {
+	var object struct {
+		ID json.Number `json:"id"`
+	}
+
+	if err := json.Unmarshal(payload, &object); err != nil || object.ID == "" {
+		return "", false
+	}
+
+	return object.ID, true
+}
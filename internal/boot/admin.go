@@ -0,0 +1,51 @@
+package boot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+)
+
+var errGrantAdminUsage = errors.New("usage: grant-admin <username>")
+
+// GrantAdmin runs the grant-admin command, it assigns admin role to the named user,
+// which is the only way to get the first admin
+func (b *boot) GrantAdmin(args []string) error {
+	if len(args) != 1 {
+		return errGrantAdminUsage
+	}
+
+	repo, err := b.Repository()
+	if err != nil {
+		return err
+	}
+
+	user, err := repo.GetUserByUsername(args[0])
+	if err != nil {
+		return fmt.Errorf("can't find user %q: %w", args[0], err)
+	}
+
+	roles, err := repo.GetUserRoles(user.ID)
+	if err != nil {
+		return err
+	}
+
+	names := []string{model.RoleAdmin}
+	for _, r := range roles {
+		if r.Name == model.RoleAdmin {
+			b.logger.Infof("User %q is an admin already", user.Username)
+			return nil
+		}
+
+		names = append(names, r.Name)
+	}
+
+	if err := repo.SetUserRoles(user.ID, names); err != nil {
+		return fmt.Errorf("can't assign admin role: %w", err)
+	}
+
+	b.logger.Infof("User %q is an admin now", user.Username)
+
+	return nil
+}
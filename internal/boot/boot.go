@@ -2,6 +2,7 @@ package boot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/dmytro-vovk/tro/internal/boot/config"
 	"github.com/rifflock/lfshook"
@@ -12,15 +13,26 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/dmytro-vovk/tro/internal/api"
+	"github.com/dmytro-vovk/tro/internal/api/handler/middleware"
+	"github.com/dmytro-vovk/tro/internal/api/lockout"
+	"github.com/dmytro-vovk/tro/internal/api/model"
 	"github.com/dmytro-vovk/tro/internal/api/repository"
+	"github.com/dmytro-vovk/tro/internal/api/repository/migrations"
 	"github.com/dmytro-vovk/tro/internal/api/service"
 	"github.com/dmytro-vovk/tro/internal/app"
+	"github.com/dmytro-vovk/tro/internal/audit"
+	"github.com/dmytro-vovk/tro/internal/mailer"
+	"github.com/dmytro-vovk/tro/internal/metrics"
+	"github.com/dmytro-vovk/tro/internal/rbac"
+	"github.com/dmytro-vovk/tro/internal/requestid"
 	"github.com/dmytro-vovk/tro/internal/webserver"
 	"github.com/dmytro-vovk/tro/internal/webserver/handlers/home"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/rpc"
 	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws"
 	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
 	"github.com/dmytro-vovk/tro/internal/webserver/router"
@@ -31,6 +43,7 @@ type boot struct {
 	container
 	viper  *viper.Viper
 	logger *logrus.Logger
+	mutex  sync.Mutex // servers are started concurrently and share components
 }
 
 func New() (*boot, error) {
@@ -106,30 +119,136 @@ func (b *boot) loadConfig() error {
 		return fmt.Errorf("error merging with %q config: %w", in, err)
 	}
 
+	// secrets are kept out of config files, JWT_SECRET may be a real env variable, not only of .env
+	if err := b.viper.BindEnv("jwt_secret"); err != nil {
+		return fmt.Errorf("error binding env variable %q: %w", "jwt_secret", err)
+	}
+
+	if s := b.viper.GetString("jwt_secret"); s != "" {
+		if err := b.viper.MergeConfigMap(map[string]interface{}{
+			"api": map[string]interface{}{
+				"jwt": map[string]interface{}{"secret": s},
+			},
+		}); err != nil {
+			return fmt.Errorf("error merging JWT_SECRET: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (b *boot) Repository() (repository.Repository, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.repository()
+}
+
+func (b *boot) repository() (repository.Repository, error) {
+	const id = "Repository"
+	if s, ok := b.Get(id).(repository.Repository); ok {
+		return s, nil
+	}
+
+	repo, err := repository.New(b.viper)
+	if err != nil {
+		return nil, fmt.Errorf("can't create repository: %w", err)
+	}
+
+	// the repository is cached once its schema is up to date, so a failed migration is retried on the next call
+	if b.viper.GetBool("database.auto_migrate") {
+		if err := b.migrate(repo); err != nil {
+			_ = repo.Close()
+
+			return nil, err
+		}
+	}
+
+	b.Set(id, repo, func() {
+		if err := repo.Close(); err != nil {
+			b.logger.Errorf("error closing %s database: %s", repo.DriverName(), err)
+		}
+	})
+
+	return repo, nil
+}
+
+func (b *boot) migrate(repo repository.Repository) error {
+	m, err := migrations.New(repo.DB())
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil {
+		return fmt.Errorf("can't migrate %s database: %w", repo.DriverName(), err)
+	}
+
 	return nil
 }
 
-func (b *boot) Application() *app.Application {
+func (b *boot) Migrator() (*migrations.Migrator, error) {
+	const id = "Migrator"
+	if s, ok := b.Get(id).(*migrations.Migrator); ok {
+		return s, nil
+	}
+
+	repo, err := b.Repository()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrations.New(repo.DB())
+	if err != nil {
+		return nil, err
+	}
+
+	b.Set(id, m, nil)
+
+	return m, nil
+}
+
+func (b *boot) Application() (*app.Application, error) {
 	const id = "Application"
 	if s, ok := b.Get(id).(*app.Application); ok {
-		return s
+		return s, nil
 	}
 
-	a := app.New(nil)
+	repo, err := b.Repository()
+	if err != nil {
+		return nil, err
+	}
+
+	a := app.New(repo)
 
 	b.Set(id, a, nil)
 
-	return a
+	return a, nil
 }
 
-func (b *boot) WebRouter() http.Handler {
+func (b *boot) WebRouter() (http.Handler, error) {
 	const id = "Web Router"
 	if s, ok := b.Get(id).(http.Handler); ok {
-		return s
+		return s, nil
+	}
+
+	wsHandler, err := b.WebsocketHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	rpcHandler, err := b.RPCHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := b.APIService()
+	if err != nil {
+		return nil, err
 	}
 
 	r := router.New(
-		router.Route("/ws", b.WebsocketHandler().Handler),
+		router.Route("/ws", requestid.Handler(audit.Source(rbac.Authenticate(srv.ParseToken, wsHandler.Handler)))),
+		router.Route("/rpc", requestid.Handler(audit.Source(rbac.Authenticate(srv.ParseToken, rpcHandler.Handler)))),
 		router.Route("/js/index.js", home.Scripts),
 		router.Route("/js/index.js.map", home.ScriptsMap),
 		router.Route("/favicon.ico", func(w http.ResponseWriter, _ *http.Request) {
@@ -141,7 +260,7 @@ func (b *boot) WebRouter() http.Handler {
 
 	b.Set(id, r, nil)
 
-	return r
+	return r, nil
 }
 
 func (b *boot) Webserver() (*webserver.Webserver, error) {
@@ -150,7 +269,11 @@ func (b *boot) Webserver() (*webserver.Webserver, error) {
 		return s, nil
 	}
 
-	handler := b.WebRouter()
+	handler, err := b.WebRouter()
+	if err != nil {
+		return nil, err
+	}
+
 	server := webserver.New(b.viper.GetString("webserver.listen"), handler, b.logger, webserver.WithTLS(handler, b.viper))
 
 	b.Set(id, server, func() {
@@ -165,72 +288,290 @@ func (b *boot) Webserver() (*webserver.Webserver, error) {
 	return server, nil
 }
 
+func (b *boot) APIService() (service.Service, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	const id = "API Service"
+	if s, ok := b.Get(id).(service.Service); ok {
+		return s, nil
+	}
+
+	repo, err := b.repository()
+	if err != nil {
+		return nil, err
+	}
+
+	mail, err := b.mailer()
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := service.New(repo, mail, b.viper.Sub("api"))
+	if err != nil {
+		return nil, fmt.Errorf("can't create API service: %w", err)
+	}
+
+	b.Set(id, srv, nil)
+
+	return srv, nil
+}
+
+func (b *boot) Auditor() (*audit.Log, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	const id = "Auditor"
+	if s, ok := b.Get(id).(*audit.Log); ok {
+		return s, nil
+	}
+
+	repo, err := b.repository()
+	if err != nil {
+		return nil, err
+	}
+
+	l := audit.New(repo)
+
+	b.Set(id, l, nil)
+
+	return l, nil
+}
+
+func (b *boot) Mailer() (mailer.Mailer, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.mailer()
+}
+
+func (b *boot) mailer() (mailer.Mailer, error) {
+	const id = "Mailer"
+	if m, ok := b.Get(id).(mailer.Mailer); ok {
+		return m, nil
+	}
+
+	var c mailer.Config
+	if err := b.viper.UnmarshalKey("mail", &c); err != nil {
+		return nil, fmt.Errorf("can't unmarshall mail config: %w", err)
+	}
+
+	m, err := mailer.New(c)
+	if err != nil {
+		return nil, fmt.Errorf("can't create mailer: %w", err)
+	}
+
+	b.Set(id, m, nil)
+
+	return m, nil
+}
+
 func (b *boot) APIServer() (*webserver.Webserver, error) {
 	const id = "API Server"
 	if server, ok := b.Get(id).(*webserver.Webserver); ok {
 		return server, nil
 	}
 
-	repo, err := repository.New(b.viper)
+	srv, err := b.APIService()
 	if err != nil {
-		return nil, fmt.Errorf("can't create API repository: %w", err)
+		return nil, err
 	}
 
-	srv, err := service.New(repo, b.viper.Sub("api"))
+	var lockoutConfig lockout.Config
+	if err := b.viper.UnmarshalKey("api.lockout", &lockoutConfig); err != nil {
+		return nil, fmt.Errorf("can't unmarshall lockout config: %w", err)
+	}
+
+	auditor, err := b.Auditor()
 	if err != nil {
-		return nil, fmt.Errorf("can't create API service: %w", err)
+		return nil, err
 	}
 
-	server := webserver.New(b.viper.GetString("api.listen"), api.NewHandler(b.logger, srv).Router(), b.logger)
+	limiter := lockout.New(lockoutConfig, func(e lockout.Event) {
+		action := model.AuditLockout
+		if e.Action == lockout.Unlocked {
+			action = model.AuditUnlock
+		}
+
+		auditor.Audit(model.AuditEvent{Action: action, Target: e.Key})
+	})
+
+	var loggingConfig middleware.LoggingConfig
+	if err := b.viper.UnmarshalKey("api.logging", &loggingConfig); err != nil {
+		return nil, fmt.Errorf("can't unmarshall API logging config: %w", err)
+	}
+
+	handler := api.NewHandler(b.logger, loggingConfig, b.viper.GetStringSlice("api.trusted_proxies"), srv, limiter, auditor)
+
+	r, err := handler.Router()
+	if err != nil {
+		return nil, fmt.Errorf("can't create API router: %w", err)
+	}
+
+	server := webserver.New(b.viper.GetString("api.listen"), r, b.logger)
 
 	b.Set(id, server, func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		if err := repo.Close(); err != nil {
-			b.logger.Errorf("error closing %s database: %s", repo.DriverName(), err)
+		if err := server.Stop(ctx); err != nil {
+			b.logger.Errorln("error stopping API server:", err)
 		}
+	})
+
+	return server, nil
+}
+
+func (b *boot) Metrics() *metrics.Registry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	const id = "Metrics"
+	if s, ok := b.Get(id).(*metrics.Registry); ok {
+		return s
+	}
+
+	r := metrics.NewRegistry()
+
+	b.Set(id, r, nil)
+
+	return r
+}
+
+func (b *boot) AdminEnabled() bool {
+	return b.viper.GetString("admin.listen") != ""
+}
+
+// AdminServer serves internal endpoints, it must not be reachable publicly
+func (b *boot) AdminServer() (*webserver.Webserver, error) {
+	const id = "Admin Server"
+	if server, ok := b.Get(id).(*webserver.Webserver); ok {
+		return server, nil
+	}
+
+	listen := b.viper.GetString("admin.listen")
+	if listen == "" {
+		return nil, errors.New("admin server address is not configured")
+	}
+
+	server := webserver.New(listen, router.New(
+		router.Route("/metrics", b.Metrics().Handler),
+	), b.logger)
+
+	b.Set(id, server, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
 
 		if err := server.Stop(ctx); err != nil {
-			b.logger.Errorln("error stopping API server:", err)
+			b.logger.Errorln("error stopping admin server:", err)
 		}
 	})
 
 	return server, nil
 }
 
-func (b *boot) WebsocketHandler() *ws.Handler {
+func (b *boot) WebsocketHandler() (*ws.Handler, error) {
 	const id = "WS Handler"
 	if s, ok := b.Get(id).(*ws.Handler); ok {
-		return s
+		return s, nil
 	}
 
-	h := ws.NewHandler(b.WSClient())
+	c, err := b.WSClient()
+	if err != nil {
+		return nil, err
+	}
+
+	h := ws.NewHandler(c)
 
 	b.Set(id, h, nil)
 
-	return h
+	return h, nil
 }
 
-func (b *boot) WSClient() *client.Client {
+func (b *boot) RPCHandler() (*rpc.Handler, error) {
+	const id = "RPC Handler"
+	if s, ok := b.Get(id).(*rpc.Handler); ok {
+		return s, nil
+	}
+
+	c, err := b.WSClient()
+	if err != nil {
+		return nil, err
+	}
+
+	h := rpc.NewHandler(c)
+
+	b.Set(id, h, nil)
+
+	return h, nil
+}
+
+func (b *boot) WSClient() (*client.Client, error) {
 	const id = "WS Client"
 	if s, ok := b.Get(id).(*client.Client); ok {
-		return s
+		return s, nil
+	}
+
+	a, err := b.Application()
+	if err != nil {
+		return nil, err
+	}
+
+	auditor, err := b.Auditor()
+	if err != nil {
+		return nil, err
 	}
 
 	s := client.New().
+		Instrument(b.Metrics()).
+		Use(rbac.Guard(rbac.Rules{
+			"example":           {}, // public
+			"code":              {},
+			"operators":         {model.PermOperatorsRead},
+			"operators.create":  {model.PermOperatorsWrite},
+			"operators.rename":  {model.PermOperatorsWrite},
+			"operators.disable": {model.PermOperatorsWrite},
+			"operators.delete":  {model.PermOperatorsWrite},
+			audit.Events:        {model.PermAuditRead},
+		})).
+		Use(audit.Calls(auditor, "operators.create", "operators.rename", "operators.disable", "operators.delete")).
 		NS("example",
-			client.NSMethod("method", b.Application().Example),
+			client.NSMethod("method", a.Example),
 		).
 		NS("code",
-			client.NSMethod("generate_image", b.Application().QR),
+			client.NSMethod("generate_image", a.QR),
+		).
+		NS("operators",
+			client.NSMethod("list", a.Operators),
+			client.NSMethod("get", a.Operator),
+			client.NSMethod("create", a.OperatorCreate),
+			client.NSMethod("rename", a.OperatorRename),
+			client.NSMethod("disable", a.OperatorDisable),
+			client.NSMethod("delete", a.OperatorDelete),
 		)
 
-	b.Application().SetStreamer(s)
+	a.SetStreamer(s)
+	auditor.SetStreamer(s)
+
+	srv, err := b.APIService()
+	if err != nil {
+		return nil, err
+	}
+
+	srv.OnRevoke(func(r model.Revocation) {
+		if n := rbac.Disconnect(s, r); n > 0 {
+			b.logger.Infof("Closed %d websocket connections of revoked sessions or API keys", n)
+		}
+	})
+
+	b.Set(id, s, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), b.viper.GetDuration("webserver.drain_timeout"))
+		defer cancel()
 
-	b.Set(id, s, nil)
+		s.Shutdown(ctx)
+	})
 
-	return s
+	return s, nil
 }
 
 func (b *boot) configureLogger() error {
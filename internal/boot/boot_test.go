@@ -0,0 +1,45 @@
+package boot
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminServerListensOnConfiguredAddress(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listen := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	log, _ := test.NewNullLogger()
+	b := &boot{viper: viper.New(), logger: log}
+	b.viper.Set("admin.listen", listen)
+
+	s, err := b.AdminServer()
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() { served <- s.Serve("Admin server") }()
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + listen + "/metrics")
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond, "nothing listens at %s", listen)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, s.Stop(ctx))
+	assert.NoError(t, <-served)
+}
@@ -1,13 +1,16 @@
 package config
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
 type WebServer struct {
-	Listen string `json:"listen"`
-	TLS    TLS    `json:"tls"`
+	Listen       string        `json:"listen"`
+	TLS          TLS           `json:"tls"`
+	DrainTimeout time.Duration `json:"drain_timeout"` // how long websocket calls may finish on shutdown
 }
 
 type TLS struct {
@@ -17,12 +20,72 @@ type TLS struct {
 }
 
 type API struct {
-	AuthMethod string `json:"auth_method"`
-	Listen     string `json:"listen"`
+	AuthMethod     string   `json:"auth_method"`
+	PasswordHasher string   `json:"password_hasher"` // argon2id or bcrypt
+	TOTPIssuer     string   `json:"totp_issuer"`     // shown in authenticator apps, TRO by default
+	PublicURL      string   `json:"public_url"`      // base URL of the API links in mail point to
+	Listen         string   `json:"listen"`
+	JWT            JWT      `json:"jwt"`
+	Avigilon       Avigilon `json:"avigilon"`
+	Lockout        Lockout  `json:"lockout"`
+}
+
+// Lockout is parsed by lockout.Config, failed sign-ins beyond allowed attempts are delayed exponentially
+type Lockout struct {
+	Attempts   int           `json:"attempts"`    // per username, 5 by default
+	IPAttempts int           `json:"ip_attempts"` // per client IP, 20 by default
+	BaseDelay  time.Duration `json:"base_delay"`  // 1s by default
+	MaxDelay   time.Duration `json:"max_delay"`   // lockout duration, 15m by default
+}
+
+// JWT is parsed by keys.Config, JWT_SECRET environment variable overrides the secret
+type JWT struct {
+	TTL        time.Duration `json:"ttl"`
+	RefreshTTL time.Duration `json:"refresh_ttl"`
+	Secret     string        `json:"secret"`
+	SigningKey string        `json:"signing_key"`
+	Keys       []JWTKey      `json:"keys"`
+}
+
+type JWTKey struct {
+	ID         string `json:"id"`
+	Algorithm  string `json:"algorithm"` // HS256, RS256 or EdDSA
+	Secret     string `json:"secret"`
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+// Avigilon is parsed by v2.Config, logins are refused until URL is set
+type Avigilon struct {
+	URL          string        `json:"url"` // base URL of the identity API
+	ClientID     string        `json:"client_id"`
+	ClientSecret string        `json:"client_secret"`
+	Timeout      time.Duration `json:"timeout"`
+	DefaultRoles []string      `json:"default_roles"` // roles of users provisioned on first login
+}
+
+// Mail is parsed by mailer.Config
+type Mail struct {
+	Transport string   `json:"transport"` // smtp, file or log
+	From      string   `json:"from"`
+	SMTP      MailSMTP `json:"smtp"`
+	Dir       string   `json:"dir"` // for file transport
+}
+
+type MailSMTP struct {
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type Admin struct {
+	Listen string `json:"listen"`
 }
 
 type Database struct {
-	DriverName string `json:"driver_name"`
+	DriverName  string `json:"driver_name"`
+	AutoMigrate bool   `json:"auto_migrate"` // apply pending migrations on start
 }
 
 func DefaultViper() *viper.Viper {
@@ -40,6 +103,7 @@ func DefaultViper() *viper.Viper {
 		"logger.rotor.max_age":            0,
 		"logger.rotor.max_backups":        0,
 		"logger.rotor.compress":           false,
+		"webserver.drain_timeout":         10 * time.Second,
 	} {
 		v.SetDefault(key, value)
 	}
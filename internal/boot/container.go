@@ -8,6 +8,7 @@ import (
 type container struct {
 	items      sync.Map
 	shutdownFn []shutdownFn
+	mutex      sync.Mutex
 	once       sync.Once
 }
 
@@ -20,10 +21,12 @@ func (c *container) Set(name string, item interface{}, fn func()) *container {
 	c.items.Store(name, item)
 
 	if fn != nil {
+		c.mutex.Lock()
 		c.shutdownFn = append(c.shutdownFn, shutdownFn{
 			name: name,
 			fn:   fn,
 		})
+		c.mutex.Unlock()
 	}
 
 	return c
@@ -39,6 +42,9 @@ func (c *container) Get(name string) interface{} {
 
 func (c *container) shutdown() {
 	c.once.Do(func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
 		for i := len(c.shutdownFn) - 1; i >= 0; i-- {
 			logrus.Infof("Shutting down %s...", c.shutdownFn[i].name)
 			c.shutdownFn[i].fn()
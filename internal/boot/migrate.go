@@ -0,0 +1,67 @@
+package boot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up|down|status|to <version>")
+
+// Migrate runs the migrate command: up applies pending migrations, down rolls back the last one,
+// status lists migrations and to <version> applies or rolls back migrations up to the version
+func (b *boot) Migrate(args []string) error {
+	// the command manages migrations itself
+	b.viper.Set("database.auto_migrate", false)
+
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	m, err := b.Migrator()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up()
+	case "down":
+		return m.Down()
+	case "to":
+		if len(args) != 2 {
+			return errMigrateUsage
+		}
+
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+
+		return m.To(version)
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+
+		return w.Flush()
+	default:
+		return errMigrateUsage
+	}
+}
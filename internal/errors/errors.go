@@ -1 +1,120 @@
+// Package errors is the domain error model. Errors have a kind telling REST and JSON-RPC transports how to respond,
+// and a message which is safe to show to clients. Errors of other types are internal, their text is only logged.
 package errors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind is a class of errors transports map to their status codes
+type Kind uint8
+
+const (
+	Internal     Kind = iota // unexpected failure, details are hidden from clients
+	Invalid                  // malformed or invalid input
+	Unauthorized             // missing or invalid credentials
+	Forbidden                // authenticated, but not allowed
+	NotFound
+	Conflict
+	TooManyRequests
+	Unsupported // not implemented by the configured provider
+	Unavailable // a dependency is not configured or down
+)
+
+// internalMessage replaces texts of internal errors in responses
+const internalMessage = "internal error"
+
+var statuses = map[Kind]int{
+	Internal:        http.StatusInternalServerError,
+	Invalid:         http.StatusBadRequest,
+	Unauthorized:    http.StatusUnauthorized,
+	Forbidden:       http.StatusForbidden,
+	NotFound:        http.StatusNotFound,
+	Conflict:        http.StatusConflict,
+	TooManyRequests: http.StatusTooManyRequests,
+	Unsupported:     http.StatusNotImplemented,
+	Unavailable:     http.StatusServiceUnavailable,
+}
+
+// Status is HTTP status code of the kind
+func (k Kind) Status() int {
+	if status, ok := statuses[k]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Error is a domain error, Message is shown to clients, the wrapped error is not
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+// New makes an error to be declared as a sentinel, e.g. ErrUserNotFound = errors.New(errors.NotFound, "user not found")
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap gives the error a kind and a public message, the error itself stays for logs and errors.Is
+func Wrap(err error, kind Kind, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns kind of the first domain error in the chain, errors of other types are internal
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	return Internal
+}
+
+// Status is HTTP status code of the error
+func Status(err error) int {
+	return KindOf(err).Status()
+}
+
+// Public returns the message of the first domain error in the chain, it's safe to show to clients,
+// messages of internal errors and errors of other types are replaced
+func Public(err error) string {
+	var e *Error
+	if !errors.As(err, &e) || e.Kind == Internal && e.Message == "" {
+		return internalMessage
+	}
+
+	return e.Message
+}
+
+// Is, As and Unwrap are those of the standard library, so the package can replace it
+
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+func As(err error, target interface{}) bool {
+	return errors.As(err, target)
+}
+
+func Unwrap(err error) error {
+	return errors.Unwrap(err)
+}
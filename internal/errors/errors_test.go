@@ -0,0 +1,40 @@
+package errors_test
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors(t *testing.T) {
+	errNotFound := errors.New(errors.NotFound, "thing not found")
+
+	for _, tc := range []struct {
+		name    string
+		err     error
+		kind    errors.Kind
+		status  int
+		message string
+	}{
+		{"sentinel", errNotFound, errors.NotFound, http.StatusNotFound, "thing not found"},
+		{"annotated sentinel", fmt.Errorf("loading thing 12: %w", errNotFound), errors.NotFound, http.StatusNotFound, "thing not found"},
+		{"wrapped", errors.Wrap(sql.ErrNoRows, errors.Conflict, "thing exists"), errors.Conflict, http.StatusConflict, "thing exists"},
+		{"foreign", sql.ErrConnDone, errors.Internal, http.StatusInternalServerError, "internal error"},
+		{"internal", errors.Wrap(sql.ErrConnDone, errors.Internal, ""), errors.Internal, http.StatusInternalServerError, "internal error"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.kind, errors.KindOf(tc.err))
+			assert.Equal(t, tc.status, errors.Status(tc.err))
+			assert.Equal(t, tc.message, errors.Public(tc.err))
+		})
+	}
+
+	wrapped := errors.Wrap(sql.ErrNoRows, errors.NotFound, "thing not found")
+	assert.True(t, errors.Is(wrapped, sql.ErrNoRows))
+	assert.EqualError(t, wrapped, "thing not found: sql: no rows in result set")
+	assert.Nil(t, errors.Wrap(nil, errors.NotFound, "thing not found"))
+}
@@ -2,17 +2,18 @@ package jsonrpc
 
 import (
 	"encoding/json"
-	"errors"
 	"strings"
+
+	"github.com/dmytro-vovk/tro/internal/errors"
 )
 
 func (r Request) Valid() error {
 	if r.Version != "2.0" {
-		return errors.New("unsupported protocol version")
+		return NewError(CodeInvalidRequest, "unsupported protocol version")
 	}
 
 	if r.Method == "" || strings.HasPrefix(r.Method, "rpc.") {
-		return errors.New("rpc-reserved or empty method")
+		return NewError(CodeInvalidRequest, "rpc-reserved or empty method")
 	}
 
 	return nil
@@ -30,10 +31,63 @@ func (r Request) Response(data json.RawMessage) Response {
 	}
 }
 
+// ErrorResponse responds with the error, see FromError
 func (r Request) ErrorResponse(err error) Response {
 	return Response{
 		ID:      r.ID,
 		Version: "2.0",
-		Error:   err.Error(),
+		Error:   FromError(err),
+	}
+}
+
+// codes of domain error kinds, other kinds get CodeServerError
+var codes = map[errors.Kind]int{
+	errors.Invalid:      CodeInvalidParams,
+	errors.Unauthorized: CodeUnauthenticated,
+	errors.Forbidden:    CodeForbidden,
+	errors.NotFound:     CodeNotFound,
+	errors.Conflict:     CodeConflict,
+}
+
+// FromError makes JSON-RPC error of the domain one with its public message, *Error is returned as is
+func FromError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
 	}
+
+	code, ok := codes[errors.KindOf(err)]
+	if !ok {
+		code = CodeServerError
+	}
+
+	return &Error{
+		Code:    code,
+		Message: errors.Public(err),
+		cause:   err,
+	}
+}
+
+func NewError(code int, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Cause returns the error the response is made of for logs, it may have details clients don't get
+func (e *Error) Cause() error {
+	if e.cause != nil {
+		return e.cause
+	}
+
+	return e
 }
@@ -14,6 +14,32 @@ type (
 		ID      int             `json:"id"`               // Must be the same as the value of the id member in the request
 		Version string          `json:"jsonrpc"`          // Must be exactly "2.0"
 		Result  json.RawMessage `json:"result,omitempty"` // The value is determined by the method invoked on the server
-		Error   string          `json:"error,omitempty"`  // Returned object when a rpc call encounters an error
+		Error   *Error          `json:"error,omitempty"`  // Returned object when a rpc call encounters an error
 	}
+
+	Error struct {
+		Code    int         `json:"code"`           // Type of the error that occurred
+		Message string      `json:"message"`        // Short description of the error
+		Data    interface{} `json:"data,omitempty"` // Additional information about the error
+
+		cause error // the error the response is made of, it's logged, but not sent
+	}
+)
+
+// Error codes defined by the specification
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error codes reserved for implementation-defined server errors
+const (
+	CodeServerError     = -32000 // Any error without more specific code
+	CodeNotFound        = -32001
+	CodeConflict        = -32002
+	CodeUnauthenticated = -32003 // The method requires a token
+	CodeForbidden       = -32004 // The token lacks permissions
 )
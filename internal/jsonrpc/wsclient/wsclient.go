@@ -0,0 +1,450 @@
+// Package wsclient is a JSON-RPC client for the websocket protocol served at /ws
+package wsclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrClosed       = errors.New("client is closed")
+	ErrDisconnected = errors.New("connection lost before response was received")
+)
+
+// TokenSource returns the access token, it is called on every (re)connect
+type TokenSource func(ctx context.Context) (string, error)
+
+// Handler receives params of the notification, it must not block
+type Handler func(params json.RawMessage)
+
+type Client struct {
+	url        string
+	dialer     *websocket.Dialer
+	header     http.Header
+	token      TokenSource
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	conn       *websocket.Conn
+	connectedC chan struct{} // closed while there is a connection
+	lastID     int
+	pending    map[int]chan jsonrpc.Response
+	handlers   map[string][]*Handler
+	mutex      sync.Mutex
+	writeMutex sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	doneC  chan struct{}
+}
+
+type Option func(*Client)
+
+// WithToken authenticates connections with the bearer token
+func WithToken(token TokenSource) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHeader adds the header to the websocket handshake request
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithDialer replaces websocket.DefaultDialer
+func WithDialer(d *websocket.Dialer) Option {
+	return func(c *Client) {
+		c.dialer = d
+	}
+}
+
+// WithBackoff sets delay bounds between reconnection attempts
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff, c.maxBackoff = min, max
+	}
+}
+
+// Dial connects to the server, the connection is restored automatically until Close is called
+func Dial(ctx context.Context, url string, options ...Option) (*Client, error) {
+	c := &Client{
+		url:        url,
+		dialer:     websocket.DefaultDialer,
+		header:     http.Header{},
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 10 * time.Second,
+		connectedC: make(chan struct{}),
+		pending:    map[int]chan jsonrpc.Response{},
+		handlers:   map[string][]*Handler{},
+		doneC:      make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.setConn(conn)
+
+	go c.run(conn)
+
+	return c, nil
+}
+
+// Close closes the connection and stops reconnecting
+func (c *Client) Close() error {
+	c.cancel()
+	<-c.doneC
+
+	return nil
+}
+
+// Call calls the method and decodes its result into result unless it is nil,
+// errors returned by the method are of *jsonrpc.Error type
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	req, err := request(method, params)
+	if err != nil {
+		return err
+	}
+
+	respC := make(chan jsonrpc.Response, 1)
+
+	c.mutex.Lock()
+	c.lastID++
+	req.ID = c.lastID
+	c.pending[req.ID] = respC
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, req.ID)
+		c.mutex.Unlock()
+	}()
+
+	if err := c.send(ctx, req); err != nil {
+		return err
+	}
+
+	select {
+	case resp, ok := <-respC:
+		if !ok {
+			return ErrDisconnected
+		}
+
+		if resp.Error != nil {
+			return resp.Error
+		}
+
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("error decoding %s result: %w", method, err)
+			}
+		}
+
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.ctx.Done():
+		return ErrClosed
+	}
+}
+
+// Notify sends the notification, the server doesn't respond to it
+func (c *Client) Notify(ctx context.Context, method string, params interface{}) error {
+	req, err := request(method, params)
+	if err != nil {
+		return err
+	}
+
+	return c.send(ctx, req)
+}
+
+// Subscribe adds the handler of the method notifications.
+// Subscriptions are restored after reconnect. Returned function removes the handler.
+func (c *Client) Subscribe(ctx context.Context, method string, handler Handler) (func(), error) {
+	h := &handler
+
+	c.mutex.Lock()
+	c.handlers[method] = append(c.handlers[method], h)
+	first := len(c.handlers[method]) == 1
+	c.mutex.Unlock()
+
+	if first {
+		if err := c.Notify(ctx, "subscribe", method); err != nil {
+			c.removeHandler(method, h)
+			return nil, err
+		}
+	}
+
+	return func() {
+		if c.removeHandler(method, h) {
+			ctx, cancel := context.WithTimeout(c.ctx, time.Second)
+			defer cancel()
+
+			if err := c.Notify(ctx, "unsubscribe", method); err != nil {
+				logrus.Printf("[%s] Error unsubscribing from %q: %s", c.url, method, err)
+			}
+		}
+	}, nil
+}
+
+// removeHandler reports whether the last handler of the method was removed
+func (c *Client) removeHandler(method string, h *Handler) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	handlers := c.handlers[method]
+	for i := range handlers {
+		if handlers[i] == h {
+			c.handlers[method] = append(handlers[:i:i], handlers[i+1:]...)
+			break
+		}
+	}
+
+	if len(c.handlers[method]) > 0 {
+		return false
+	}
+
+	delete(c.handlers, method)
+
+	return true
+}
+
+func request(method string, params interface{}) (jsonrpc.Request, error) {
+	req := jsonrpc.Request{
+		Version: "2.0",
+		Method:  method,
+	}
+
+	if params != nil {
+		payload, err := json.Marshal(params)
+		if err != nil {
+			return req, fmt.Errorf("error encoding %s params: %w", method, err)
+		}
+
+		req.Params = payload
+	}
+
+	return req, nil
+}
+
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	header := c.header.Clone()
+
+	if c.token != nil {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting access token: %w", err)
+		}
+
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	conn, _, err := c.dialer.DialContext(ctx, c.url, header)
+	if err != nil {
+		return nil, fmt.Errorf("error dialing %s: %w", c.url, err)
+	}
+
+	return conn, nil
+}
+
+// send waits for the connection and writes the request
+func (c *Client) send(ctx context.Context, req jsonrpc.Request) error {
+	for {
+		c.mutex.Lock()
+		conn, connectedC := c.conn, c.connectedC
+		c.mutex.Unlock()
+
+		if conn == nil {
+			select {
+			case <-connectedC:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.ctx.Done():
+				return ErrClosed
+			}
+		}
+
+		c.writeMutex.Lock()
+		err := conn.WriteJSON(req)
+		c.writeMutex.Unlock()
+
+		if err != nil {
+			return fmt.Errorf("error sending %s: %w", req.Method, err)
+		}
+
+		return nil
+	}
+}
+
+// setConn reports false if the client was closed meanwhile
+func (c *Client) setConn(conn *websocket.Conn) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.ctx.Err() != nil {
+		_ = conn.Close()
+		return false
+	}
+
+	c.conn = conn
+	close(c.connectedC)
+
+	return true
+}
+
+// dropConn fails pending calls since their responses are lost with the connection
+func (c *Client) dropConn() {
+	c.mutex.Lock()
+	c.conn = nil
+	c.connectedC = make(chan struct{})
+
+	for id, respC := range c.pending {
+		close(respC)
+		delete(c.pending, id)
+	}
+	c.mutex.Unlock()
+}
+
+func (c *Client) run(conn *websocket.Conn) {
+	defer close(c.doneC)
+
+	go func() {
+		<-c.ctx.Done()
+
+		c.mutex.Lock()
+		if c.conn != nil {
+			c.writeMutex.Lock()
+			_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			c.writeMutex.Unlock()
+			_ = c.conn.Close()
+		}
+		c.mutex.Unlock()
+	}()
+
+	for {
+		c.receiver(conn)
+		_ = conn.Close()
+		c.dropConn()
+
+		if conn = c.reconnect(); conn == nil || !c.setConn(conn) {
+			return
+		}
+
+		c.resubscribe()
+	}
+}
+
+// reconnect dials with exponential backoff until success or Close
+func (c *Client) reconnect() *websocket.Conn {
+	for delay := c.minBackoff; ; {
+		select {
+		case <-time.After(delay):
+		case <-c.ctx.Done():
+			return nil
+		}
+
+		conn, err := c.dial(c.ctx)
+		if err == nil {
+			logrus.Printf("[%s] Reconnected", c.url)
+			return conn
+		}
+
+		if c.ctx.Err() != nil {
+			return nil
+		}
+
+		logrus.Printf("[%s] Error reconnecting: %s", c.url, err)
+
+		if delay *= 2; delay > c.maxBackoff {
+			delay = c.maxBackoff
+		}
+	}
+}
+
+func (c *Client) resubscribe() {
+	c.mutex.Lock()
+	methods := make([]string, 0, len(c.handlers))
+	for method := range c.handlers {
+		methods = append(methods, method)
+	}
+	c.mutex.Unlock()
+
+	for _, method := range methods {
+		if err := c.Notify(c.ctx, "subscribe", method); err != nil {
+			logrus.Printf("[%s] Error resubscribing to %q: %s", c.url, method, err)
+		}
+	}
+}
+
+// message is anything server can send: a response or a notification
+type message struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonrpc.Error  `json:"error"`
+}
+
+func (c *Client) receiver(conn *websocket.Conn) {
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			if c.ctx.Err() == nil {
+				logrus.Printf("[%s] Connection lost: %s", c.url, err)
+			}
+
+			return
+		}
+
+		if msg.Method != "" {
+			c.dispatch(msg.Method, msg.Params)
+			continue
+		}
+
+		c.mutex.Lock()
+		respC, ok := c.pending[msg.ID]
+		delete(c.pending, msg.ID)
+		c.mutex.Unlock()
+
+		if !ok {
+			logrus.Printf("[%s] Response to unknown request %d", c.url, msg.ID)
+			continue
+		}
+
+		respC <- jsonrpc.Response{
+			ID:      msg.ID,
+			Version: "2.0",
+			Result:  msg.Result,
+			Error:   msg.Error,
+		}
+	}
+}
+
+func (c *Client) dispatch(method string, params json.RawMessage) {
+	c.mutex.Lock()
+	handlers := append([]*Handler(nil), c.handlers[method]...)
+	c.mutex.Unlock()
+
+	for _, h := range handlers {
+		(*h)(params)
+	}
+}
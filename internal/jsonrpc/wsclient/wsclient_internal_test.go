@@ -0,0 +1,98 @@
+package wsclient
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client/clienttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echo struct {
+	Message string `json:"message"`
+}
+
+func TestClient(t *testing.T) {
+	server := clienttest.NewServer(t, client.New().
+		NS("test",
+			client.NSMethod("echo", func(r echo) (*echo, error) { return &r, nil }),
+		),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := Dial(ctx, server.WSURL(), WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	require.NoError(t, err)
+	defer c.Close()
+
+	var resp echo
+	require.NoError(t, c.Call(ctx, "test.echo", echo{Message: "hello"}, &resp))
+	assert.Equal(t, "hello", resp.Message)
+
+	err = c.Call(ctx, "test.missing", nil, nil)
+	var rpcErr *jsonrpc.Error
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.Equal(t, jsonrpc.CodeMethodNotFound, rpcErr.Code)
+	}
+
+	received := make(chan echo, 1)
+	unsubscribe, err := c.Subscribe(ctx, "test.stream", func(params json.RawMessage) {
+		var msg echo
+		if err := json.Unmarshal(params, &msg); err == nil {
+			select {
+			case received <- msg:
+			default:
+			}
+		}
+	})
+	require.NoError(t, err)
+
+	waitSubscribers(t, server, 1)
+
+	// drop the connection, the client must reconnect and restore subscription
+	c.mutex.Lock()
+	_ = c.conn.Close()
+	c.mutex.Unlock()
+
+	// the server may still hold the dropped connection, so keep notifying until delivered
+	for delivered := false; !delivered; {
+		server.Client.Notify("test.stream", echo{Message: "after reconnect"})
+
+		select {
+		case msg := <-received:
+			assert.Equal(t, "after reconnect", msg.Message)
+			delivered = true
+		case <-time.After(20 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("No notification after reconnect")
+		}
+	}
+
+	require.NoError(t, c.Call(ctx, "test.echo", echo{Message: "again"}, &resp))
+	assert.Equal(t, "again", resp.Message)
+
+	unsubscribe()
+	waitSubscribers(t, server, 0)
+
+	cancelled, cancelCall := context.WithCancel(ctx)
+	cancelCall()
+	assert.ErrorIs(t, c.Call(cancelled, "test.echo", echo{}, nil), context.Canceled)
+}
+
+func waitSubscribers(t *testing.T, server *clienttest.Server, n int) {
+	t.Helper()
+
+	for deadline := time.Now().Add(clienttest.DefaultTimeout); server.Client.Subscribers("test.stream") != n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d subscribers", n)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+type fileMailer struct {
+	from    string
+	dir     string
+	counter uint64
+}
+
+// NewFile returns mailer writing messages into .eml files in the directory
+func NewFile(from, dir string) (Mailer, error) {
+	if dir == "" {
+		return nil, errors.New("mail directory is not configured")
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &fileMailer{from: from, dir: dir}, nil
+}
+
+func (m *fileMailer) Send(msg Message) error {
+	now := time.Now()
+
+	data, err := format(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%d.eml", now.Format("20060102-150405"), atomic.AddUint64(&m.counter, 1))
+
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o640)
+}
@@ -0,0 +1,18 @@
+package mailer
+
+import "github.com/sirupsen/logrus"
+
+type logMailer struct {
+	from string
+}
+
+// NewLog returns mailer printing messages to the log, for development only as tokens get into logs
+func NewLog(from string) Mailer {
+	return logMailer{from: from}
+}
+
+func (m logMailer) Send(msg Message) error {
+	logrus.Printf("Mail from %s to %s: %s\n%s", m.from, msg.To, msg.Subject, msg.Body)
+
+	return nil
+}
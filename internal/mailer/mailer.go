@@ -0,0 +1,79 @@
+// Package mailer sends plain text mail through SMTP or, for development, into files or the log
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+)
+
+var (
+	errHeaderInjection = errors.New("mail header contains line break")
+	errNoTransport     = errors.New("mail transport is not configured")
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(m Message) error
+}
+
+type Config struct {
+	Transport string     `mapstructure:"transport"` // smtp, file or log, required so that log isn't picked by mistake
+	From      string     `mapstructure:"from"`
+	SMTP      SMTPConfig `mapstructure:"smtp"`
+	Dir       string     `mapstructure:"dir"` // where file transport puts messages
+}
+
+func New(c Config) (Mailer, error) {
+	switch c.Transport {
+	case "smtp":
+		return NewSMTP(c.From, c.SMTP)
+	case "file":
+		return NewFile(c.From, c.Dir)
+	case "log":
+		return NewLog(c.From), nil
+	case "":
+		return nil, errNoTransport
+	default:
+		return nil, fmt.Errorf("mail transport %q doesn't exist", c.Transport)
+	}
+}
+
+// format makes RFC 5322 message with quoted-printable UTF-8 body
+func format(from string, m Message, date time.Time) ([]byte, error) {
+	for _, header := range []string{from, m.To, m.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errHeaderInjection
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
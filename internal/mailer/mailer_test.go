@@ -0,0 +1,73 @@
+package mailer_test
+
+import (
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/mailer"
+	"github.com/dmytro-vovk/tro/internal/mailer/mailertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var message = mailer.Message{
+	To:      "alice@example.com",
+	Subject: "Пароль",
+	Body:    "Reset link:\nhttps://tro.example.com/reset-password?token=" + strings.Repeat("x", 80) + "\n",
+}
+
+func TestSMTP(t *testing.T) {
+	server := mailertest.NewServer(t)
+
+	m, err := mailer.New(mailer.Config{
+		Transport: "smtp",
+		From:      "tro@example.com",
+		SMTP:      mailer.SMTPConfig{Host: server.Host(), Port: server.Port()},
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.Send(message))
+	require.True(t, server.Wait(time.Second))
+
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "tro@example.com", messages[0].From)
+	assert.Equal(t, []string{"alice@example.com"}, messages[0].To)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(messages[0].Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, message.Subject, subject)
+
+	body, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(messages[0].Body)))
+	require.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(message.Body, "\n", "\r\n"), string(body), "long lines survive soft breaks")
+
+	assert.Error(t, m.Send(mailer.Message{To: "alice@example.com\r\nBcc: eve@example.com"}))
+}
+
+func TestFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	m, err := mailer.New(mailer.Config{Transport: "file", From: "tro@example.com", Dir: dir})
+	require.NoError(t, err)
+	require.NoError(t, m.Send(message))
+	require.NoError(t, m.Send(message))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: alice@example.com\r\n")
+}
+
+func TestNoTransport(t *testing.T) {
+	_, err := mailer.New(mailer.Config{From: "tro@example.com"})
+	assert.Error(t, err, "log transport must be picked explicitly")
+}
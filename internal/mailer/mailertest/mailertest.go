@@ -0,0 +1,180 @@
+// Package mailertest provides an SMTP stand-in server keeping received messages
+package mailertest
+
+import (
+	"bufio"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type Message struct {
+	From   string
+	To     []string
+	Header mail.Header
+	Body   string // raw, as transferred
+}
+
+// Server accepts mail without authentication, it will be closed on test cleanup
+type Server struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	messages []Message
+	received chan struct{}
+}
+
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error starting SMTP server: %s", err)
+	}
+
+	s := &Server{
+		listener: listener,
+		received: make(chan struct{}, 100),
+	}
+
+	go s.serve()
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return s
+}
+
+// Host and Port are the address the server listens on
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.listener.Addr().String())
+
+	return host
+}
+
+func (s *Server) Port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+
+	return port
+}
+
+// Messages returns messages received so far
+func (s *Server) Messages() []Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// Wait waits for the next message, it returns false on timeout
+func (s *Server) Wait(timeout time.Duration) bool {
+	select {
+	case <-s.received:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) bool {
+		_, err := conn.Write([]byte(line + "\r\n"))
+		return err == nil
+	}
+
+	if !reply("220 mailertest ESMTP") {
+		return
+	}
+
+	var msg Message
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch {
+		case verb == "EHLO" || verb == "HELO":
+			reply("250 mailertest")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			msg = Message{From: address(line)}
+			reply("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			msg.To = append(msg.To, address(line))
+			reply("250 OK")
+		case verb == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			data, err := readData(r)
+			if err != nil {
+				return
+			}
+
+			parsed, err := mail.ReadMessage(strings.NewReader(data))
+			if err != nil {
+				reply("554 Malformed message")
+				continue
+			}
+
+			body := new(strings.Builder)
+			_, _ = bufio.NewReader(parsed.Body).WriteTo(body)
+			msg.Header, msg.Body = parsed.Header, body.String()
+
+			s.mutex.Lock()
+			s.messages = append(s.messages, msg)
+			s.mutex.Unlock()
+
+			s.received <- struct{}{}
+
+			reply("250 OK")
+		case verb == "RSET" || verb == "NOOP":
+			reply("250 OK")
+		case verb == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// readData reads the message up to the terminating dot line, undoing dot-stuffing
+func readData(r *bufio.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+
+		if strings.TrimRight(line, "\r\n") == "." {
+			return b.String(), nil
+		}
+
+		b.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
+
+func address(line string) string {
+	i := strings.Index(line, ":")
+
+	return strings.Trim(strings.TrimSpace(line[i+1:]), "<>")
+}
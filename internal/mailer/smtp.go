@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"` // PLAIN authentication is used if set, it requires TLS except on localhost
+	Password string `mapstructure:"password"`
+}
+
+type smtpMailer struct {
+	from string
+	addr string
+	auth smtp.Auth
+}
+
+// NewSMTP returns mailer sending through the server, STARTTLS is used when the server offers it
+func NewSMTP(from string, c SMTPConfig) (Mailer, error) {
+	if c.Host == "" {
+		return nil, errors.New("SMTP host is not configured")
+	}
+
+	if c.Port == "" {
+		c.Port = "25"
+	}
+
+	m := &smtpMailer{
+		from: from,
+		addr: net.JoinHostPort(c.Host, c.Port),
+	}
+
+	if c.Username != "" {
+		m.auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+
+	return m, nil
+}
+
+func (m *smtpMailer) Send(msg Message) error {
+	data, err := format(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, data)
+}
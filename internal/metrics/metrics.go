@@ -0,0 +1,345 @@
+// Package metrics collects counters, gauges and histograms and exposes them in Prometheus text format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultBuckets are latency buckets in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type collector interface {
+	write(w io.Writer) error
+}
+
+type Registry struct {
+	collectors []collector
+	mutex      sync.Mutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mutex.Lock()
+	r.collectors = append(r.collectors, c)
+	r.mutex.Unlock()
+}
+
+// Counter registers monotonically increasing value partitioned by labels
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{family: newFamily(name, help, "counter", labels)}
+	r.register(c)
+
+	return c
+}
+
+// Gauge registers value which can go up and down partitioned by labels
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{family: newFamily(name, help, "gauge", labels)}
+	r.register(g)
+
+	return g
+}
+
+// GaugeFunc registers gauge which values are collected on every scrape,
+// fn returns values by label value, so only one label is supported
+func (r *Registry) GaugeFunc(name, help, label string, fn func() map[string]float64) {
+	r.register(&gaugeFunc{
+		family: newFamily(name, help, "gauge", []string{label}),
+		fn:     fn,
+	})
+}
+
+// Histogram registers observations counted in buckets partitioned by labels
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		family:  newFamily(name, help, "histogram", labels),
+		buckets: append([]float64(nil), buckets...),
+		series:  map[string]*histogramSeries{},
+	}
+	sort.Float64s(h.buckets)
+	r.register(h)
+
+	return h
+}
+
+// Handler writes all registered metrics
+func (r *Registry) Handler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := r.Write(w); err != nil {
+		logrus.Printf("Error writing metrics to %s: %s", req.RemoteAddr, err)
+	}
+}
+
+// Write writes all registered metrics in Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.mutex.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mutex.Unlock()
+
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// family holds metric description and values of its series keyed by label values
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	values map[string]float64
+	mutex  sync.Mutex
+}
+
+func newFamily(name, help, kind string, labels []string) family {
+	return family{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		values: map[string]float64{},
+	}
+}
+
+func (f *family) key(labelValues []string) string {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+func (f *family) add(v float64, labelValues []string) {
+	key := f.key(labelValues)
+
+	f.mutex.Lock()
+	f.values[key] += v
+	f.mutex.Unlock()
+}
+
+func (f *family) set(v float64, labelValues []string) {
+	key := f.key(labelValues)
+
+	f.mutex.Lock()
+	f.values[key] = v
+	f.mutex.Unlock()
+}
+
+func (f *family) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+	return err
+}
+
+func (f *family) write(w io.Writer) error {
+	f.mutex.Lock()
+	values := make(map[string]float64, len(f.values))
+	for key, v := range f.values {
+		values[key] = v
+	}
+	f.mutex.Unlock()
+
+	if len(f.labels) == 0 {
+		if _, ok := values[""]; !ok {
+			values[""] = 0
+		}
+	}
+
+	return f.writeValues(w, values)
+}
+
+func (f *family) writeValues(w io.Writer, values map[string]float64) error {
+	if err := f.header(w); err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(values) {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", f.name, f.labelPairs(key, ""), formatFloat(values[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// labelPairs renders labels of the series key, extra is appended as is
+func (f *family) labelPairs(key, extra string) string {
+	var pairs []string
+
+	if len(f.labels) > 0 {
+		for i, v := range strings.Split(key, "\xff") {
+			pairs = append(pairs, f.labels[i]+`="`+escapeLabel(v)+`"`)
+		}
+	}
+
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+type Counter struct {
+	family
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.add(1, labelValues)
+}
+
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("counter can't decrease")
+	}
+
+	c.add(v, labelValues)
+}
+
+type Gauge struct {
+	family
+}
+
+func (g *Gauge) Inc(labelValues ...string) {
+	g.add(1, labelValues)
+}
+
+func (g *Gauge) Dec(labelValues ...string) {
+	g.add(-1, labelValues)
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.set(v, labelValues)
+}
+
+type gaugeFunc struct {
+	family
+	fn func() map[string]float64
+}
+
+func (g *gaugeFunc) write(w io.Writer) error {
+	return g.writeValues(w, g.fn())
+}
+
+type Histogram struct {
+	family
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // cumulative counts are calculated on write
+	count  uint64
+	sum    float64
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	if err := h.header(w); err != nil {
+		return err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(key, `le="`+formatFloat(le)+`"`), cumulative); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labelPairs(key, `le="+Inf"`), s.count,
+			h.name, h.labelPairs(key, ""), formatFloat(s.sum),
+			h.name, h.labelPairs(key, ""), s.count,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string { return helpReplacer.Replace(s) }
+
+func escapeLabel(s string) string { return labelReplacer.Replace(s) }
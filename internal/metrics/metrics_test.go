@@ -0,0 +1,54 @@
+package metrics_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	connections := r.Gauge("connections", "Active connections.")
+	connections.Inc()
+	connections.Inc()
+	connections.Dec()
+
+	calls := r.Counter("calls_total", "Calls\nby method.", "method")
+	calls.Inc("b")
+	calls.Add(2, `a"1`)
+
+	r.GaugeFunc("subscriptions", "Subscriptions.", "topic", func() map[string]float64 {
+		return map[string]float64{"example.stream": 3}
+	})
+
+	latency := r.Histogram("latency_seconds", "Latency.", []float64{0.1, 1}, "method")
+	latency.Observe(0.05, "a")
+	latency.Observe(0.5, "a")
+	latency.Observe(5, "a")
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf))
+
+	assert.Equal(t, `# HELP connections Active connections.
+# TYPE connections gauge
+connections 1
+# HELP calls_total Calls\nby method.
+# TYPE calls_total counter
+calls_total{method="a\"1"} 2
+calls_total{method="b"} 1
+# HELP subscriptions Subscriptions.
+# TYPE subscriptions gauge
+subscriptions{topic="example.stream"} 3
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{method="a",le="0.1"} 1
+latency_seconds_bucket{method="a",le="1"} 2
+latency_seconds_bucket{method="a",le="+Inf"} 3
+latency_seconds_sum{method="a"} 5.55
+latency_seconds_count{method="a"} 3
+`, buf.String())
+}
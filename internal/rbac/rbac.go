@@ -0,0 +1,146 @@
+// Package rbac checks permissions of websocket and HTTP JSON-RPC callers,
+// REST API routes are checked by auth.Handler.Require
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
+	"github.com/gorilla/websocket"
+)
+
+type identityKey struct{}
+
+// Parser validates access token and returns its identity, the context is of the request
+type Parser func(ctx context.Context, token string) (model.Identity, error)
+
+// Rules are permissions required by RPC namespaces or methods, keyed by namespace ("operators")
+// or full method name ("operators.create"), method rules take precedence. Methods and topics without rules
+// are denied, public ones have rules with no permissions.
+type Rules map[string][]model.Permission
+
+func WithIdentity(ctx context.Context, identity model.Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns identity of the caller, false for anonymous callers
+func IdentityFrom(ctx context.Context) (model.Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(model.Identity)
+
+	return identity, ok
+}
+
+// Authenticate puts identity of the bearer token into the request context.
+// Browsers can't set headers on websocket connections, so the token of a websocket handshake may come
+// in access_token query parameter, other requests must use the header to keep tokens out of URLs and logs.
+// Requests without a token pass anonymously, requests with an invalid one are rejected.
+// The context is done when the token expires, so websocket connections are closed then.
+func Authenticate(parse Parser, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if websocket.IsWebSocketUpgrade(r) {
+			token = r.URL.Query().Get("access_token")
+		}
+
+		if header := r.Header.Get("Authorization"); header != "" {
+			token = strings.TrimPrefix(header, "Bearer ")
+		}
+
+		if token == "" {
+			next(w, r)
+			return
+		}
+
+		identity, err := parse(r.Context(), token)
+		if err != nil {
+			requestid.Log(r.Context()).Printf("[%s] Invalid token: %s", r.RemoteAddr, err)
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := WithIdentity(r.Context(), identity)
+		if !identity.ExpiresAt.IsZero() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, identity.ExpiresAt)
+			defer cancel()
+		}
+
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// Guard rejects calls of callers lacking permissions the rules require
+func Guard(rules Rules) client.Middleware {
+	return func(next client.HandlerFunc) client.HandlerFunc {
+		return func(ctx context.Context, req jsonrpc.Request) jsonrpc.Response {
+			required, ok := rules.For(req.Method)
+			if !ok {
+				return req.ErrorResponse(jsonrpc.NewError(jsonrpc.CodeForbidden, "method not allowed"))
+			}
+
+			if len(required) == 0 {
+				return next(ctx, req)
+			}
+
+			identity, ok := IdentityFrom(ctx)
+			if !ok {
+				return req.ErrorResponse(jsonrpc.NewError(jsonrpc.CodeUnauthenticated, "authentication required"))
+			}
+
+			if !identity.Can(required...) {
+				return req.ErrorResponse(jsonrpc.NewError(jsonrpc.CodeForbidden, "permission denied"))
+			}
+
+			return next(ctx, req)
+		}
+	}
+}
+
+// Disconnect closes websocket connections of the revoked sessions or API key,
+// API key connections are kept when sessions are revoked
+func Disconnect(c *client.Client, r model.Revocation) int {
+	return c.Disconnect(func(ctx context.Context) bool {
+		identity, ok := IdentityFrom(ctx)
+		if !ok {
+			return false
+		}
+
+		if r.APIKeyID != 0 || identity.APIKeyID != 0 {
+			return identity.APIKeyID == r.APIKeyID
+		}
+
+		if r.SessionID != "" {
+			return identity.SessionID == r.SessionID
+		}
+
+		return identity.UserID == r.UserID
+	}, reason(r))
+}
+
+// For returns permissions required by the method, false if there is no rule for it
+func (r Rules) For(method string) ([]model.Permission, bool) {
+	if permissions, ok := r[method]; ok {
+		return permissions, true
+	}
+
+	if i := strings.Index(method, "."); i > 0 {
+		permissions, ok := r[method[:i]]
+
+		return permissions, ok
+	}
+
+	return nil, false
+}
+
+func reason(r model.Revocation) string {
+	if r.APIKeyID != 0 {
+		return "API key revoked"
+	}
+
+	return "session revoked"
+}
@@ -0,0 +1,179 @@
+package rbac_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/api/model"
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/dmytro-vovk/tro/internal/rbac"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client/clienttest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+var identities = map[string]model.Identity{
+	"viewer":   {UserID: 1, SessionID: "s1", Permissions: []model.Permission{model.PermOperatorsRead}},
+	"operator": {UserID: 2, SessionID: "s2", Permissions: []model.Permission{model.PermOperatorsRead, model.PermOperatorsWrite}},
+	"script":   {UserID: 2, APIKeyID: 1, Permissions: []model.Permission{model.PermOperatorsRead}},
+}
+
+func parse(_ context.Context, token string) (model.Identity, error) {
+	if identity, ok := identities[token]; ok {
+		return identity, nil
+	}
+
+	return model.Identity{}, errors.New("invalid token")
+}
+
+func header(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func errorCode(err error) int {
+	var rpcErr *jsonrpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code
+	}
+
+	return 0
+}
+
+func TestGuard(t *testing.T) {
+	ok := func() error { return nil }
+	c := client.New().
+		Use(rbac.Guard(rbac.Rules{
+			"example":          {},
+			"operators":        {model.PermOperatorsRead},
+			"operators.create": {model.PermOperatorsWrite},
+		})).
+		NS("operators",
+			client.NSMethod("list", ok),
+			client.NSMethod("create", ok),
+		).
+		NS("example",
+			client.NSMethod("method", ok),
+		).
+		NS("code",
+			client.NSMethod("generate_image", ok),
+		)
+
+	server := clienttest.NewServer(t, c, func(next http.HandlerFunc) http.HandlerFunc {
+		return rbac.Authenticate(parse, next)
+	})
+
+	anonymous, viewer, operator := server.Dial(), server.DialHeader(header("viewer")), server.DialHeader(header("operator"))
+
+	assert.NoError(t, anonymous.Call("example.method", nil, nil), "rules without permissions are public")
+	assert.Equal(t, jsonrpc.CodeForbidden, errorCode(operator.Call("code.generate_image", nil, nil)), "no rule, no access")
+	assert.Equal(t, jsonrpc.CodeUnauthenticated, errorCode(anonymous.Call("operators.list", nil, nil)))
+
+	assert.NoError(t, viewer.Call("operators.list", nil, nil))
+	assert.Equal(t, jsonrpc.CodeForbidden, errorCode(viewer.Call("operators.create", nil, nil)))
+
+	assert.NoError(t, operator.Call("operators.create", nil, nil))
+
+	assert.Equal(t, jsonrpc.CodeUnauthenticated, errorCode(anonymous.Call("subscribe", "operators.changed", nil)))
+	viewer.Subscribe("operators.changed")
+	assert.Equal(t, 1, c.Subscribers("operators.changed"))
+
+	c.Notify("operators.changed", "update")
+	viewer.ExpectNotification("operators.changed", nil)
+	anonymous.ExpectNoNotification("operators.changed", 50*time.Millisecond)
+
+	_, resp, err := websocket.DefaultDialer.Dial(server.WSURL(), header("forged"))
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	c := client.New().NS("example", client.NSMethod("method", func() error { return nil }))
+	server := clienttest.NewServer(t, c, func(next http.HandlerFunc) http.HandlerFunc {
+		return rbac.Authenticate(parse, next)
+	})
+
+	anonymous, viewer := server.Dial(), server.DialHeader(header("viewer"))
+	operator, script := server.DialHeader(header("operator")), server.DialHeader(header("script"))
+	for _, conn := range []*clienttest.Conn{anonymous, viewer, operator, script} {
+		assert.NoError(t, conn.Call("example.method", nil, nil))
+	}
+
+	assert.Equal(t, 1, rbac.Disconnect(c, model.Revocation{UserID: 1, SessionID: "s1"}))
+	assert.Equal(t, websocket.ClosePolicyViolation, viewer.ExpectClose())
+	assert.NoError(t, operator.Call("example.method", nil, nil))
+
+	assert.Equal(t, 1, rbac.Disconnect(c, model.Revocation{UserID: 2}), "API keys aren't sessions")
+	assert.Equal(t, websocket.ClosePolicyViolation, operator.ExpectClose())
+	assert.NoError(t, script.Call("example.method", nil, nil))
+	assert.NoError(t, anonymous.Call("example.method", nil, nil))
+
+	assert.Zero(t, rbac.Disconnect(c, model.Revocation{UserID: 2, APIKeyID: 2}), "other keys")
+	assert.Equal(t, 1, rbac.Disconnect(c, model.Revocation{UserID: 2, APIKeyID: 1}))
+	assert.Equal(t, websocket.ClosePolicyViolation, script.ExpectClose())
+	assert.NoError(t, anonymous.Call("example.method", nil, nil))
+}
+
+func TestExpiry(t *testing.T) {
+	c := client.New().NS("example", client.NSMethod("method", func() error { return nil }))
+	server := clienttest.NewServer(t, c, func(next http.HandlerFunc) http.HandlerFunc {
+		return rbac.Authenticate(func(ctx context.Context, token string) (model.Identity, error) {
+			identity, err := parse(ctx, token)
+			if identity.APIKeyID == 0 {
+				identity.ExpiresAt = time.Now().Add(100 * time.Millisecond)
+			}
+
+			return identity, err
+		}, next)
+	})
+
+	viewer, script := server.DialHeader(header("viewer")), server.DialHeader(header("script"))
+	assert.NoError(t, viewer.Call("example.method", nil, nil))
+	assert.Equal(t, websocket.ClosePolicyViolation, viewer.ExpectClose())
+	assert.NoError(t, script.Call("example.method", nil, nil), "API keys don't expire")
+}
+
+func TestAuthenticateQuery(t *testing.T) {
+	var identity model.Identity
+	var authenticated bool
+	handler := rbac.Authenticate(parse, func(_ http.ResponseWriter, r *http.Request) {
+		identity, authenticated = rbac.IdentityFrom(r.Context())
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/rpc?access_token=viewer", nil)
+	handler(httptest.NewRecorder(), r)
+	assert.False(t, authenticated, "query token is only accepted on websocket handshakes")
+
+	r = httptest.NewRequest(http.MethodGet, "/ws?access_token=viewer", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	handler(httptest.NewRecorder(), r)
+	assert.True(t, authenticated)
+	assert.Equal(t, identities["viewer"], identity)
+}
+
+func TestRulesFor(t *testing.T) {
+	rules := rbac.Rules{
+		"example":          nil,
+		"operators":        {model.PermOperatorsRead},
+		"operators.create": {model.PermOperatorsWrite},
+	}
+
+	for method, expected := range map[string][]model.Permission{
+		"operators.list":   {model.PermOperatorsRead},
+		"operators.create": {model.PermOperatorsWrite},
+		"example.method":   nil,
+	} {
+		required, ok := rules.For(method)
+		assert.True(t, ok, method)
+		assert.Equal(t, expected, required, method)
+	}
+
+	_, ok := rules.For("code.generate_image")
+	assert.False(t, ok)
+}
@@ -0,0 +1,109 @@
+// Package requestid correlates requests across REST, RPC calls, logs and the audit log.
+// The id is taken from X-Request-ID header or made, kept in the context and echoed in responses.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	Header = "X-Request-ID"
+	Field  = "request_id" // of log entries
+)
+
+const (
+	// MaxLength is the length of the longest id, derived ones included, audit_log.request_id column is as long
+	MaxLength = 64
+	// ids of clients longer than that are replaced, so that derived ones stay within MaxLength, it fits UUIDs
+	maxClientLength = MaxLength - len("-18446744073709551615")
+)
+
+type key struct{}
+
+// New makes a random id
+func New() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Of returns id the client sent or a new one, ids with characters other than letters, digits and ".-_:" are replaced
+func Of(r *http.Request) string {
+	if id := r.Header.Get(Header); valid(id) {
+		return id
+	}
+
+	return New()
+}
+
+func valid(id string) bool {
+	if id == "" || len(id) > maxClientLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '-', c == '_', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// From returns id of the context, empty if there is none
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+
+	return id
+}
+
+// Derive gives n-th call made in the context its own id, e.g. calls over a websocket connection
+// get "<connection id>-1", "<connection id>-2" and so on
+func Derive(ctx context.Context, n uint64) context.Context {
+	id := From(ctx)
+	if id == "" {
+		id = New()
+	}
+
+	return With(ctx, fmt.Sprintf("%s-%d", id, n))
+}
+
+// Handler puts id of the request into its context and the response header
+func Handler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := Of(r)
+		w.Header().Set(Header, id)
+
+		next(w, r.WithContext(With(r.Context(), id)))
+	}
+}
+
+// Entry is the logger with id of the context
+func Entry(ctx context.Context, log *logrus.Logger) *logrus.Entry {
+	entry := logrus.NewEntry(log)
+	if id := From(ctx); id != "" {
+		entry = entry.WithField(Field, id)
+	}
+
+	return entry
+}
+
+// Log is the standard logger with id of the context
+func Log(ctx context.Context) *logrus.Entry {
+	return Entry(ctx, logrus.StandardLogger())
+}
@@ -0,0 +1,61 @@
+package requestid_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	var got string
+	handler := requestid.Handler(func(w http.ResponseWriter, r *http.Request) {
+		got = requestid.From(r.Context())
+	})
+
+	for _, tc := range []struct {
+		name, header string
+		kept         bool
+	}{
+		{"uuid", "0b4bcd3c-5c62-4e6b-9d0e-2f1a7a9e0c11", true},
+		{"trace", "trace:42.span_7", true},
+		{"missing", "", false},
+		{"spaces", "id with spaces", false},
+		{"newline", "id\nforged: entry", false},
+		{"longest", strings.Repeat("a", 43), true},
+		{"long", strings.Repeat("a", 44), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set(requestid.Header, tc.header)
+			}
+
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			assert.NotEmpty(t, got)
+			assert.Equal(t, got, w.Header().Get(requestid.Header), "echoed")
+			assert.Equal(t, tc.kept, got == tc.header)
+		})
+	}
+}
+
+func TestDerive(t *testing.T) {
+	ctx := requestid.With(context.Background(), "conn")
+	assert.Equal(t, "conn-1", requestid.From(requestid.Derive(ctx, 1)))
+	assert.Equal(t, "conn-2", requestid.From(requestid.Derive(ctx, 2)))
+
+	assert.Empty(t, requestid.From(context.Background()))
+	assert.Regexp(t, `^[0-9a-f]{16}-1$`, requestid.From(requestid.Derive(context.Background(), 1)))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(requestid.Header, strings.Repeat("a", 43))
+	longest := requestid.Derive(requestid.With(ctx, requestid.Of(r)), math.MaxUint64)
+	assert.Len(t, requestid.From(longest), requestid.MaxLength, "fits audit_log.request_id")
+}
@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/dmytro-vovk/tro/internal/requestid"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
+)
+
+const maxBodySize = 1 << 20
+
+var errEmptyBatch = jsonrpc.NewError(jsonrpc.CodeInvalidRequest, "empty batch")
+
+// Handler makes methods registered on the websocket client available over HTTP POST
+type Handler struct {
+	client *client.Client
+}
+
+func NewHandler(c *client.Client) *Handler {
+	return &Handler{client: c}
+}
+
+// Handler handles single and batch JSON-RPC requests
+func (h *Handler) Handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		requestid.Log(r.Context()).Printf("[%s] Error reading request: %s", r.RemoteAddr, err)
+		h.write(w, r, jsonrpc.Request{}.ErrorResponse(jsonrpc.NewError(jsonrpc.CodeParseError, err.Error())))
+		return
+	}
+
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+		h.batch(w, r, body)
+		return
+	}
+
+	if resp, ok := h.handle(r, body); ok {
+		h.write(w, r, resp)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) batch(w http.ResponseWriter, r *http.Request, body []byte) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		requestid.Log(r.Context()).Printf("[%s] Error decoding batch: %s", r.RemoteAddr, err)
+		h.write(w, r, jsonrpc.Request{}.ErrorResponse(jsonrpc.NewError(jsonrpc.CodeParseError, err.Error())))
+		return
+	}
+
+	if len(batch) == 0 {
+		h.write(w, r, jsonrpc.Request{}.ErrorResponse(errEmptyBatch))
+		return
+	}
+
+	responses := make([]jsonrpc.Response, 0, len(batch))
+	for _, msg := range batch {
+		if resp, ok := h.handle(r, msg); ok {
+			responses = append(responses, resp)
+		}
+	}
+
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.write(w, r, responses)
+}
+
+// handle reports false for notifications, they don't get a response
+func (h *Handler) handle(r *http.Request, msg []byte) (jsonrpc.Response, bool) {
+	var req jsonrpc.Request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		requestid.Log(r.Context()).Printf("[%s] Error decoding request: %s", r.RemoteAddr, err)
+		requestid.Log(r.Context()).Printf("[%s] Request: %s", r.RemoteAddr, msg)
+		return req.ErrorResponse(jsonrpc.NewError(jsonrpc.CodeParseError, err.Error())), true
+	}
+
+	if err := req.Valid(); err != nil {
+		requestid.Log(r.Context()).Printf("[%s] Invalid request object: %s", r.RemoteAddr, err)
+		return req.ErrorResponse(err), true
+	}
+
+	resp := h.client.Dispatch(r.Context(), req)
+	if resp.Error != nil {
+		requestid.Log(r.Context()).Printf("[%s] RPC call %s(%s) error: %s", r.RemoteAddr, req.Method, req.Params, resp.Error.Cause())
+	}
+
+	return resp, !req.IsNotification()
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		requestid.Log(r.Context()).Printf("Error writing response to %s: %s", r.RemoteAddr, err)
+	}
+}
@@ -0,0 +1,92 @@
+package rpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/rpc"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
+	"github.com/stretchr/testify/assert"
+)
+
+type echo struct {
+	Message string `json:"message"`
+}
+
+func TestHandler(t *testing.T) {
+	h := rpc.NewHandler(client.New().
+		NS("test",
+			client.NSMethod("echo", func(r echo) (*echo, error) { return &r, nil }),
+		),
+	)
+
+	testCases := []struct {
+		name     string
+		method   string
+		body     string
+		code     int
+		expected string
+	}{
+		{
+			name:     "single request",
+			method:   http.MethodPost,
+			body:     `{"jsonrpc":"2.0","id":1,"method":"test.echo","params":{"message":"hi"}}`,
+			code:     http.StatusOK,
+			expected: `{"id":1,"jsonrpc":"2.0","result":{"message":"hi"}}`,
+		},
+		{
+			name:     "unknown method",
+			method:   http.MethodPost,
+			body:     `{"jsonrpc":"2.0","id":1,"method":"test.missing"}`,
+			code:     http.StatusOK,
+			expected: `{"id":1,"jsonrpc":"2.0","error":{"code":-32601,"message":"method \"test.missing\" doesn't exist"}}`,
+		},
+		{
+			name:   "batch with notification",
+			method: http.MethodPost,
+			body: `[
+				{"jsonrpc":"2.0","id":1,"method":"test.echo","params":{"message":"first"}},
+				{"jsonrpc":"2.0","method":"test.echo","params":{"message":"skipped"}},
+				{"jsonrpc":"1.0","id":3,"method":"test.echo"}
+			]`,
+			code: http.StatusOK,
+			expected: `[
+				{"id":1,"jsonrpc":"2.0","result":{"message":"first"}},
+				{"id":3,"jsonrpc":"2.0","error":{"code":-32600,"message":"unsupported protocol version"}}
+			]`,
+		},
+		{
+			name:     "empty batch",
+			method:   http.MethodPost,
+			body:     `[]`,
+			code:     http.StatusOK,
+			expected: `{"id":0,"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch"}}`,
+		},
+		{
+			name:   "notification only",
+			method: http.MethodPost,
+			body:   `{"jsonrpc":"2.0","method":"test.echo","params":{"message":"hi"}}`,
+			code:   http.StatusNoContent,
+		},
+		{
+			name:   "wrong HTTP method",
+			method: http.MethodGet,
+			code:   http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.Handler(w, httptest.NewRequest(tc.method, "/rpc", strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.code, w.Code)
+
+			if tc.expected != "" {
+				assert.JSONEq(t, tc.expected, w.Body.String())
+			}
+		})
+	}
+}
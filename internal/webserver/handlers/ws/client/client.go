@@ -1,16 +1,20 @@
 package client
 
 import (
-	"github.com/sirupsen/logrus"
+	"context"
 	"sync"
 	"time"
 
+	"github.com/dmytro-vovk/tro/internal/requestid"
 	"github.com/gorilla/websocket"
 )
 
 type Client struct {
 	methods     map[string]rpcHandler
+	middleware  []Middleware
 	connections map[string]*connection
+	metrics     *clientMetrics
+	draining    bool
 	mutex       sync.RWMutex
 }
 
@@ -55,12 +59,59 @@ func (c *Client) Notify(method string, params interface{}) {
 	c.mutex.Unlock()
 }
 
-// Run handles single connection
-func (c *Client) Run(conn *websocket.Conn) {
-	start, addr := time.Now(), conn.RemoteAddr().String()
-	logrus.Printf("[%s] Websocket client connected", addr)
-	c.connections[addr] = NewConnection(conn, c.methods)
-	c.connections[addr].Run()
+// Subscribers returns the number of connections subscribed to the method
+func (c *Client) Subscribers(method string) int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var n int
+	for addr := range c.connections {
+		if c.connections[addr].subscribed(method) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Subscriptions returns the number of subscribed connections by method
+func (c *Client) Subscriptions() map[string]int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	subscriptions := map[string]int{}
+	for addr := range c.connections {
+		for _, method := range c.connections[addr].topics() {
+			subscriptions[method]++
+		}
+	}
+
+	return subscriptions
+}
+
+// Run handles single connection, ctx is passed to every call made over it
+func (c *Client) Run(ctx context.Context, conn *websocket.Conn) {
+	start, addr, log := time.Now(), conn.RemoteAddr().String(), requestid.Log(ctx)
+	log.Printf("[%s] Websocket client connected", addr)
+	connection := NewConnection(ctx, conn, c.Dispatch, c.authorizeSubscription, c.metrics)
+
+	c.mutex.Lock()
+	if c.draining {
+		c.mutex.Unlock()
+		log.Printf("[%s] Websocket client rejected: %s", addr, errShuttingDown)
+		reject(conn)
+
+		return
+	}
+	c.connections[addr] = connection
+	c.mutex.Unlock()
+	c.metrics.connectionOpened()
+
+	connection.Run()
+
+	c.mutex.Lock()
 	delete(c.connections, addr)
-	logrus.Printf("[%s] Websocket client disconnected after %s", addr, time.Since(start))
+	c.mutex.Unlock()
+	c.metrics.connectionClosed()
+	log.Printf("[%s] Websocket client disconnected after %s", addr, time.Since(start))
 }
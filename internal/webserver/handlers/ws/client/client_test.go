@@ -0,0 +1,121 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/errors"
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client/clienttest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoRequest struct {
+	Message string `json:"message"`
+}
+
+type echoResponse struct {
+	Message string `json:"message"`
+}
+
+func newTestClient() *client.Client {
+	return client.New().
+		NS("test",
+			client.NSMethod("echo", func(r echoRequest) (*echoResponse, error) {
+				return &echoResponse{Message: r.Message}, nil
+			}),
+			client.NSMethod("fail", func() error {
+				return fmt.Errorf("failed")
+			}),
+			client.NSMethod("conflict", func() error {
+				return errors.Wrap(fmt.Errorf("duplicate key"), errors.Conflict, "already exists")
+			}),
+		)
+}
+
+func TestClientCall(t *testing.T) {
+	conn := clienttest.NewServer(t, newTestClient()).Dial()
+
+	var resp echoResponse
+	assert.NoError(t, conn.Call("test.echo", echoRequest{Message: "hello"}, &resp))
+	assert.Equal(t, "hello", resp.Message)
+
+	// details of errors are only logged
+	assert.EqualError(t, conn.Call("test.fail", nil, nil), "internal error")
+
+	err := conn.Call("test.conflict", nil, nil)
+	assert.EqualError(t, err, "already exists")
+	assert.Equal(t, jsonrpc.CodeConflict, jsonrpc.FromError(err).Code)
+	assert.EqualError(t, conn.Call("test.missing", nil, nil), `method "test.missing" doesn't exist`)
+}
+
+func TestClientNotify(t *testing.T) {
+	server := clienttest.NewServer(t, newTestClient())
+	subscribed, other := server.Dial(), server.Dial()
+
+	subscribed.Subscribe("test.stream")
+	assert.Equal(t, 1, server.Client.Subscribers("test.stream"))
+	assert.Equal(t, jsonrpc.CodeInvalidParams, jsonrpc.FromError(other.Call("subscribe", 1, nil)).Code)
+
+	server.Client.Notify("test.stream", echoResponse{Message: "first"})
+
+	var msg echoResponse
+	subscribed.ExpectNotification("test.stream", &msg)
+	assert.Equal(t, "first", msg.Message)
+	other.ExpectNoNotification("test.stream", 50*time.Millisecond)
+
+	subscribed.Unsubscribe("test.stream")
+	assert.Equal(t, 0, server.Client.Subscribers("test.stream"))
+
+	server.Client.Notify("test.stream", echoResponse{Message: "second"})
+	subscribed.ExpectNoNotification("test.stream", 50*time.Millisecond)
+}
+
+func TestClientShutdown(t *testing.T) {
+	release := make(chan struct{})
+	server := clienttest.NewServer(t, newTestClient().
+		NS("test",
+			client.NSMethod("slow", func() (*echoResponse, error) {
+				<-release
+				return &echoResponse{Message: "done"}, nil
+			}),
+		),
+	)
+	conn := server.Dial()
+
+	result := make(chan error, 1)
+	go func() {
+		var resp echoResponse
+		result <- conn.Call("test.slow", nil, &resp)
+	}()
+
+	// let the slow call reach the server before shutting down
+	time.Sleep(50 * time.Millisecond)
+
+	shutdown := make(chan struct{})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), clienttest.DefaultTimeout)
+		defer cancel()
+
+		server.Client.Shutdown(ctx)
+		close(shutdown)
+	}()
+
+	conn.ExpectNotification(client.GoingAwayMethod, nil)
+	assert.True(t, server.Client.Draining())
+
+	_, resp, err := websocket.DefaultDialer.Dial(server.WSURL(), nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	close(release)
+	assert.NoError(t, <-result)
+	assert.Equal(t, websocket.CloseGoingAway, conn.ExpectClose())
+	<-shutdown
+}
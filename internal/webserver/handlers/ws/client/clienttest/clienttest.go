@@ -0,0 +1,343 @@
+// Package clienttest provides utilities for websocket RPC integration testing.
+package clienttest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws"
+	"github.com/dmytro-vovk/tro/internal/webserver/handlers/ws/client"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultTimeout is how long helpers wait for the server before failing the test
+const DefaultTimeout = 2 * time.Second
+
+// Server is a client.Client served over websocket by httptest.Server
+type Server struct {
+	*httptest.Server
+	Client *client.Client
+	t      testing.TB
+}
+
+// NewServer starts the server, it will be closed on test cleanup.
+// Middleware wraps the websocket handler, the first one is the outermost.
+func NewServer(t testing.TB, c *client.Client, middleware ...func(http.HandlerFunc) http.HandlerFunc) *Server {
+	t.Helper()
+
+	handler := http.HandlerFunc(ws.NewHandler(c).Handler)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	s := &Server{
+		Server: httptest.NewServer(handler),
+		Client: c,
+		t:      t,
+	}
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// WSURL returns websocket address of the server
+func (s *Server) WSURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + "/ws"
+}
+
+// Dial connects a new test connection to the server, it will be closed on test cleanup
+func (s *Server) Dial() *Conn {
+	s.t.Helper()
+
+	return s.DialHeader(nil)
+}
+
+// DialHeader connects with the handshake request header
+func (s *Server) DialHeader(header http.Header) *Conn {
+	s.t.Helper()
+
+	ws, _, err := websocket.DefaultDialer.Dial(s.WSURL(), header)
+	if err != nil {
+		s.t.Fatalf("Error dialing %s: %s", s.WSURL(), err)
+	}
+
+	c := &Conn{
+		t:             s.t,
+		ws:            ws,
+		timeout:       DefaultTimeout,
+		pending:       map[int]chan jsonrpc.Response{},
+		notifications: make(chan jsonrpc.Request, 64),
+		doneC:         make(chan struct{}),
+		closedC:       make(chan struct{}),
+	}
+	s.t.Cleanup(c.Close)
+
+	go c.receiver()
+
+	return c
+}
+
+// Conn is a JSON-RPC test connection
+type Conn struct {
+	t       testing.TB
+	ws      *websocket.Conn
+	timeout time.Duration
+	lastID  int
+	pending map[int]chan jsonrpc.Response
+	// notifications received but not yet expected
+	backlog       []jsonrpc.Request
+	notifications chan jsonrpc.Request
+	doneC         chan struct{}
+	closedC       chan struct{} // closed when the server closes the connection
+	closeErr      error
+	closeOnce     sync.Once
+	writeMutex    sync.Mutex
+	mutex         sync.Mutex
+}
+
+// message is anything server can send: a response or a notification
+type message struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonrpc.Error  `json:"error"`
+}
+
+// SetTimeout changes how long helpers wait for the server
+func (c *Conn) SetTimeout(d time.Duration) *Conn {
+	c.timeout = d
+	return c
+}
+
+// Close closes the connection
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() {
+		close(c.doneC)
+
+		c.writeMutex.Lock()
+		defer c.writeMutex.Unlock()
+
+		_ = c.ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		_ = c.ws.Close()
+	})
+}
+
+// Call makes RPC call and decodes the result into result unless it is nil.
+// Error returned by the method is returned as *jsonrpc.Error, transport errors fail the test.
+func (c *Conn) Call(method string, params, result interface{}) error {
+	c.t.Helper()
+
+	req := jsonrpc.Request{
+		Version: "2.0",
+		Method:  method,
+	}
+
+	if params != nil {
+		payload, err := json.Marshal(params)
+		if err != nil {
+			c.t.Fatalf("Error encoding %s params: %s", method, err)
+		}
+
+		req.Params = payload
+	}
+
+	respC := make(chan jsonrpc.Response, 1)
+
+	c.mutex.Lock()
+	c.lastID++
+	req.ID = c.lastID
+	c.pending[req.ID] = respC
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, req.ID)
+		c.mutex.Unlock()
+	}()
+
+	c.send(req)
+
+	select {
+	case resp := <-respC:
+		if resp.Error != nil {
+			return resp.Error
+		}
+
+		if result != nil {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				c.t.Fatalf("Error decoding %s result %s: %s", method, resp.Result, err)
+			}
+		}
+
+		return nil
+	case <-time.After(c.timeout):
+		c.t.Fatalf("No response to %s in %s", method, c.timeout)
+	case <-c.doneC:
+		c.t.Fatalf("Connection closed while waiting for %s response", method)
+	case <-c.closedC:
+		c.t.Fatalf("Server closed connection while waiting for %s response: %s", method, c.closeErr)
+	}
+
+	return nil
+}
+
+// Notify sends JSON-RPC notification to the server
+func (c *Conn) Notify(method string, params interface{}) {
+	c.t.Helper()
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatalf("Error encoding %s params: %s", method, err)
+	}
+
+	c.send(jsonrpc.Request{
+		Version: "2.0",
+		Method:  method,
+		Params:  payload,
+	})
+}
+
+// Subscribe subscribes to the method, the server acknowledges the subscription before it returns
+func (c *Conn) Subscribe(method string) {
+	c.t.Helper()
+
+	if err := c.Call("subscribe", method, nil); err != nil {
+		c.t.Fatalf("Error subscribing to %s: %s", method, err)
+	}
+}
+
+// Unsubscribe unsubscribes from the method, the server acknowledges it before it returns
+func (c *Conn) Unsubscribe(method string) {
+	c.t.Helper()
+
+	if err := c.Call("unsubscribe", method, nil); err != nil {
+		c.t.Fatalf("Error unsubscribing from %s: %s", method, err)
+	}
+}
+
+// ExpectNotification waits for notification of the method and decodes its params into params unless it is nil.
+// Notifications of other methods received meanwhile are kept for later expectations.
+func (c *Conn) ExpectNotification(method string, params interface{}) {
+	c.t.Helper()
+
+	notice, ok := c.next(method, c.timeout)
+	if !ok {
+		c.t.Fatalf("No %s notification in %s", method, c.timeout)
+	}
+
+	if params != nil {
+		if err := json.Unmarshal(notice.Params, params); err != nil {
+			c.t.Fatalf("Error decoding %s notification params %s: %s", method, notice.Params, err)
+		}
+	}
+}
+
+// ExpectNoNotification makes sure there is no notification of the method during the wait
+func (c *Conn) ExpectNoNotification(method string, wait time.Duration) {
+	c.t.Helper()
+
+	if notice, ok := c.next(method, wait); ok {
+		c.t.Fatalf("Unexpected %s notification: %s", method, notice.Params)
+	}
+}
+
+func (c *Conn) next(method string, wait time.Duration) (jsonrpc.Request, bool) {
+	for i, notice := range c.backlog {
+		if notice.Method == method {
+			c.backlog = append(c.backlog[:i], c.backlog[i+1:]...)
+			return notice, true
+		}
+	}
+
+	timeout := time.After(wait)
+
+	for {
+		select {
+		case notice := <-c.notifications:
+			if notice.Method == method {
+				return notice, true
+			}
+
+			c.backlog = append(c.backlog, notice)
+		case <-timeout:
+			return jsonrpc.Request{}, false
+		case <-c.doneC:
+			return jsonrpc.Request{}, false
+		}
+	}
+}
+
+// ExpectClose waits for the server to close the connection and returns the close code
+func (c *Conn) ExpectClose() int {
+	c.t.Helper()
+
+	select {
+	case <-c.closedC:
+	case <-time.After(c.timeout):
+		c.t.Fatalf("Server didn't close connection in %s", c.timeout)
+	}
+
+	var closeErr *websocket.CloseError
+	if errors.As(c.closeErr, &closeErr) {
+		return closeErr.Code
+	}
+
+	return websocket.CloseAbnormalClosure
+}
+
+func (c *Conn) send(req jsonrpc.Request) {
+	c.t.Helper()
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if err := c.ws.WriteJSON(req); err != nil {
+		c.t.Fatalf("Error sending %s: %s", req.Method, err)
+	}
+}
+
+func (c *Conn) receiver() {
+	for {
+		var msg message
+		if err := c.ws.ReadJSON(&msg); err != nil {
+			c.closeErr = err
+			close(c.closedC)
+
+			return
+		}
+
+		if msg.Method != "" {
+			select {
+			case c.notifications <- jsonrpc.Request{Version: "2.0", Method: msg.Method, Params: msg.Params}:
+			default:
+				c.t.Logf("Notification %s dropped: too many unexpected notifications", msg.Method)
+			}
+
+			continue
+		}
+
+		c.mutex.Lock()
+		respC, ok := c.pending[msg.ID]
+		c.mutex.Unlock()
+
+		if !ok {
+			c.t.Logf("Unexpected response with id %d", msg.ID)
+			continue
+		}
+
+		respC <- jsonrpc.Response{
+			ID:      msg.ID,
+			Version: "2.0",
+			Result:  msg.Result,
+			Error:   msg.Error,
+		}
+	}
+}
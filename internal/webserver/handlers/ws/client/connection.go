@@ -1,45 +1,70 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/sirupsen/logrus"
 	"sync"
+	"sync/atomic"
 
 	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/dmytro-vovk/tro/internal/requestid"
 	"github.com/gorilla/websocket"
 )
 
 type connection struct {
+	ctx           context.Context
+	log           *logrus.Entry // with request id of the connection
+	calls         uint64        // counter of calls, their request ids are derived from the connection's one
 	conn          *websocket.Conn
-	methods       map[string]rpcHandler
+	dispatch      HandlerFunc
+	authorize     func(ctx context.Context, topic string) error
+	metrics       *clientMetrics
 	subscriptions map[string]struct{}
 	sendC         chan interface{}
 	doneC         chan struct{}
+	inflight      sync.WaitGroup // calls being handled
+	draining      bool
 	mutex         sync.RWMutex
 }
 
-func NewConnection(conn *websocket.Conn, methods map[string]rpcHandler) *connection {
+func NewConnection(
+	ctx context.Context,
+	conn *websocket.Conn,
+	dispatch HandlerFunc,
+	authorize func(ctx context.Context, topic string) error,
+	metrics *clientMetrics,
+) *connection {
 	return &connection{
+		ctx:           ctx,
+		log:           requestid.Log(ctx),
 		conn:          conn,
-		methods:       methods,
+		dispatch:      dispatch,
+		authorize:     authorize,
+		metrics:       metrics,
 		subscriptions: map[string]struct{}{},
 		sendC:         make(chan interface{}, 1),
 		doneC:         make(chan struct{}),
 	}
 }
 
+// Run serves the connection until it's closed or its context is done, e.g. when its access token expires
 func (c *connection) Run() {
 	go c.receiver()
 	go c.sender()
 
-	<-c.doneC
+	select {
+	case <-c.doneC:
+	case <-c.ctx.Done():
+		c.disconnect(expiredReason)
+		<-c.doneC
+	}
 }
 
 func (c *connection) Notify(method string, params interface{}) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
-	if _, ok := c.subscriptions[method]; !ok {
+	if _, ok := c.subscriptions[method]; !ok || c.draining {
 		return
 	}
 
@@ -59,11 +84,12 @@ func (c *connection) Notify(method string, params interface{}) {
 func (c *connection) notify(notice jsonrpc.Request) bool {
 	select {
 	case c.sendC <- notice:
-		//logrus.Printf("[%s] Sending message:\n%s", c.conn.RemoteAddr(), notice) // too noisy
+		//c.log.Printf("[%s] Sending message:\n%s", c.conn.RemoteAddr(), notice) // too noisy
 		return true
 	default:
 		// try to change channel size
-		logrus.Printf("[%s] Couldn't send notification:\n%s", c.conn.RemoteAddr(), notice)
+		c.log.Printf("[%s] Couldn't send notification:\n%+v", c.conn.RemoteAddr(), notice)
+		c.metrics.notificationDropped(notice.Method)
 		return false
 	}
 }
@@ -73,18 +99,28 @@ func (c *connection) receiver() {
 		msgType, msg, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				logrus.Printf("[%s] Unexpected close error: %v", c.conn.RemoteAddr(), err)
+				c.log.Printf("[%s] Unexpected close error: %v", c.conn.RemoteAddr(), err)
 			}
 
 			close(c.doneC)
 			return
 		}
 
+		c.metrics.messageReceived()
+
 		switch msgType {
 		case websocket.TextMessage:
-			go c.handleTextMessage(msg)
+			if !c.startCall() {
+				go c.rejectTextMessage(msg)
+				continue
+			}
+
+			go func() {
+				defer c.inflight.Done()
+				c.handleTextMessage(msg)
+			}()
 		default:
-			logrus.Printf("[%s] Unknown message type: %d", c.conn.RemoteAddr(), msgType)
+			c.log.Printf("[%s] Unknown message type: %d", c.conn.RemoteAddr(), msgType)
 		}
 	}
 }
@@ -96,12 +132,20 @@ func (c *connection) sender() {
 			switch t := resp.(type) {
 			case jsonrpc.Response:
 			case jsonrpc.Request:
+			case closeMessage:
+				if err := c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(t.code, t.text)); err != nil {
+					c.log.Printf("[%s] Error sending close message: %s", c.conn.RemoteAddr(), err)
+				}
+
+				continue
 			default:
 				logrus.Panicf("unknown response type: %T", t)
 			}
 
 			if err := c.conn.WriteJSON(resp); err != nil {
-				logrus.Printf("[%s] Error sending message: %s", c.conn.RemoteAddr(), err)
+				c.log.Printf("[%s] Error sending message: %s", c.conn.RemoteAddr(), err)
+			} else {
+				c.metrics.messageSent()
 			}
 		case <-c.doneC:
 			return
@@ -112,15 +156,15 @@ func (c *connection) sender() {
 func (c *connection) handleTextMessage(msg []byte) {
 	var req jsonrpc.Request
 	if err := json.Unmarshal(msg, &req); err != nil {
-		logrus.Printf("[%s] Error decoding request: %s", c.conn.RemoteAddr(), err)
-		logrus.Printf("[%s] Request: %s", c.conn.RemoteAddr(), msg)
-		c.sendC <- req.ErrorResponse(err)
+		c.log.Printf("[%s] Error decoding request: %s", c.conn.RemoteAddr(), err)
+		c.log.Printf("[%s] Request: %s", c.conn.RemoteAddr(), msg)
+		c.send(req.ErrorResponse(jsonrpc.NewError(jsonrpc.CodeParseError, err.Error())))
 		return
 	}
 
 	if err := req.Valid(); err != nil {
-		logrus.Printf("[%s] Invalid request object: %s", c.conn.RemoteAddr(), err)
-		c.sendC <- req.ErrorResponse(err)
+		c.log.Printf("[%s] Invalid request object: %s", c.conn.RemoteAddr(), err)
+		c.send(req.ErrorResponse(err))
 		return
 	}
 
@@ -129,49 +173,93 @@ func (c *connection) handleTextMessage(msg []byte) {
 
 func (c *connection) handleRequest(req jsonrpc.Request) {
 	if req.IsNotification() {
-		c.handleNotification(req)
+		_ = c.handleSubscription(req)
 		return
 	}
 
-	if fn, ok := c.methods[req.Method]; ok {
-		data, err := fn.call(req.Params)
-		if err != nil {
-			logrus.Printf("[%s] RPC call %s(%s) error: %s", c.conn.RemoteAddr(), req.Method, req.Params, err.Error())
-			c.sendC <- req.ErrorResponse(err)
-		} else {
-			c.sendC <- req.Response(data)
+	// subscriptions made by calls are acknowledged, so clients know when notifications start
+	if req.Method == "subscribe" || req.Method == "unsubscribe" {
+		if err := c.handleSubscription(req); err != nil {
+			c.send(req.ErrorResponse(err))
+			return
 		}
-	} else {
-		logrus.Printf("[%s] Requested method %q doesn't exist", c.conn.RemoteAddr(), req.Method)
-		c.sendC <- req.ErrorResponse(fmt.Errorf("method %q doesn't exist", req.Method))
+
+		c.send(req.Response(json.RawMessage("true")))
+
+		return
+	}
+
+	ctx := requestid.Derive(c.ctx, atomic.AddUint64(&c.calls, 1))
+
+	resp := c.dispatch(ctx, req)
+	if resp.Error != nil {
+		requestid.Log(ctx).Printf("[%s] RPC call %s(%s) error: %s", c.conn.RemoteAddr(), req.Method, req.Params, resp.Error.Cause())
+	}
+
+	c.send(resp)
+}
+
+// send queues the message unless the connection is closed
+func (c *connection) send(msg interface{}) {
+	select {
+	case c.sendC <- msg:
+	case <-c.doneC:
 	}
 }
 
-func (c *connection) handleNotification(notice jsonrpc.Request) {
+// handleSubscription subscribes or unsubscribes the connection, other notifications are ignored
+func (c *connection) handleSubscription(req jsonrpc.Request) error {
 	var method string
-	if err := json.Unmarshal(notice.Params, &method); err != nil {
-		logrus.Printf("[%s] Error decoding method name: %s", c.conn.RemoteAddr(), err)
-		logrus.Printf("[%s] Params: %s", c.conn.RemoteAddr(), notice.Params)
-		return
+	if err := json.Unmarshal(req.Params, &method); err != nil {
+		c.log.Printf("[%s] Error decoding method name: %s", c.conn.RemoteAddr(), err)
+		c.log.Printf("[%s] Params: %s", c.conn.RemoteAddr(), req.Params)
+		return jsonrpc.NewError(jsonrpc.CodeInvalidParams, "params must be a method name")
 	}
 
-	switch notice.Method {
+	switch req.Method {
 	case "subscribe":
+		if err := c.authorize(c.ctx, method); err != nil {
+			c.log.Printf("[%s] Subscription to %q denied: %s", c.conn.RemoteAddr(), method, err)
+			return err
+		}
+
 		c.subscribe(method)
 	case "unsubscribe":
 		c.unsubscribe(method)
 	}
+
+	return nil
+}
+
+func (c *connection) subscribed(method string) bool {
+	c.mutex.RLock()
+	_, ok := c.subscriptions[method]
+	c.mutex.RUnlock()
+
+	return ok
+}
+
+func (c *connection) topics() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	topics := make([]string, 0, len(c.subscriptions))
+	for method := range c.subscriptions {
+		topics = append(topics, method)
+	}
+
+	return topics
 }
 
 func (c *connection) subscribe(method string) {
-	logrus.Printf("[%s] Subscribing to %q", c.conn.RemoteAddr(), method)
+	c.log.Printf("[%s] Subscribing to %q", c.conn.RemoteAddr(), method)
 	c.mutex.Lock()
 	c.subscriptions[method] = struct{}{}
 	c.mutex.Unlock()
 }
 
 func (c *connection) unsubscribe(method string) {
-	logrus.Printf("[%s] Unsubscribing from %q", c.conn.RemoteAddr(), method)
+	c.log.Printf("[%s] Unsubscribing from %q", c.conn.RemoteAddr(), method)
 	c.mutex.Lock()
 	delete(c.subscriptions, method)
 	c.mutex.Unlock()
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// disconnectTimeout is how long a disconnected client is given to acknowledge closing
+	disconnectTimeout = 5 * time.Second
+	// expiredReason closes connections which contexts are done, the client may connect again with a new token
+	expiredReason = "authentication expired"
+)
+
+// Disconnect closes connections which contexts match, e.g. of revoked sessions, and returns their number.
+// Calls in progress aren't waited for.
+func (c *Client) Disconnect(match func(ctx context.Context) bool, reason string) int {
+	c.mutex.RLock()
+	var matched []*connection
+	for addr := range c.connections {
+		if match(c.connections[addr].ctx) {
+			matched = append(matched, c.connections[addr])
+		}
+	}
+	c.mutex.RUnlock()
+
+	for _, conn := range matched {
+		go conn.disconnect(reason)
+	}
+
+	return len(matched)
+}
+
+func (c *connection) disconnect(reason string) {
+	c.mutex.Lock()
+	c.draining = true
+	c.mutex.Unlock()
+
+	defer c.conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), disconnectTimeout)
+	defer cancel()
+
+	if !c.sendWithin(ctx, closeMessage{code: websocket.ClosePolicyViolation, text: reason}) {
+		return
+	}
+
+	select {
+	case <-c.doneC:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+)
+
+// HandlerFunc handles RPC request and returns the response to it
+type HandlerFunc func(ctx context.Context, req jsonrpc.Request) jsonrpc.Response
+
+// Middleware wraps every RPC call regardless of the transport it came from
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Use adds middleware, the first one added is the outermost
+func (c *Client) Use(middleware ...Middleware) *Client {
+	c.middleware = append(c.middleware, middleware...)
+	return c
+}
+
+// Dispatch calls the requested method through the middleware chain
+func (c *Client) Dispatch(ctx context.Context, req jsonrpc.Request) jsonrpc.Response {
+	start := time.Now()
+	resp := c.chain(c.call)(ctx, req)
+
+	// unknown methods are counted together to keep metric cardinality bounded
+	method := req.Method
+	if _, ok := c.methods[method]; !ok {
+		method = "unknown"
+	}
+	c.metrics.called(method, time.Since(start), resp.Error != nil)
+
+	return resp
+}
+
+func (c *Client) call(ctx context.Context, req jsonrpc.Request) jsonrpc.Response {
+	fn, ok := c.methods[req.Method]
+	if !ok {
+		return req.ErrorResponse(jsonrpc.NewError(jsonrpc.CodeMethodNotFound, fmt.Sprintf("method %q doesn't exist", req.Method)))
+	}
+
+	data, err := fn.call(ctx, req.Params)
+	if err != nil {
+		return req.ErrorResponse(err)
+	}
+
+	return req.Response(data)
+}
+
+// authorizeSubscription passes the subscription through the middleware chain as a notification of the topic,
+// so guards apply to topics the same way they apply to methods
+func (c *Client) authorizeSubscription(ctx context.Context, topic string) error {
+	resp := c.chain(func(_ context.Context, req jsonrpc.Request) jsonrpc.Response {
+		return req.Response(nil)
+	})(ctx, jsonrpc.Request{Version: "2.0", Method: topic})
+
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	return nil
+}
+
+func (c *Client) chain(next HandlerFunc) HandlerFunc {
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		next = c.middleware[i](next)
+	}
+
+	return next
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// GoingAwayMethod is sent to every connection on shutdown, clients should reconnect elsewhere
+const GoingAwayMethod = "server.going_away"
+
+var errShuttingDown = errors.New("server is shutting down")
+
+type goingAway struct {
+	Reason string `json:"reason"`
+}
+
+// closeMessage is queued after the last response, so the close frame doesn't overtake it
+type closeMessage struct {
+	code int
+	text string
+}
+
+// Draining reports whether the client no longer accepts connections
+func (c *Client) Draining() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.draining
+}
+
+// Shutdown stops accepting connections and drains existing ones: clients are told to go away,
+// in-flight calls are given until ctx is done to finish, then connections are closed
+func (c *Client) Shutdown(ctx context.Context) {
+	c.mutex.Lock()
+	c.draining = true
+	connections := make([]*connection, 0, len(c.connections))
+	for addr := range c.connections {
+		connections = append(connections, c.connections[addr])
+	}
+	c.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, conn := range connections {
+		wg.Add(1)
+		go func(conn *connection) {
+			defer wg.Done()
+			conn.drain(ctx)
+		}(conn)
+	}
+	wg.Wait()
+}
+
+// reject closes the connection accepted while shutting down
+func reject(conn *websocket.Conn) {
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, errShuttingDown.Error())
+	if err := conn.WriteMessage(websocket.CloseMessage, msg); err != nil {
+		logrus.Printf("[%s] Error sending close message: %s", conn.RemoteAddr(), err)
+	}
+
+	_ = conn.Close()
+}
+
+// startCall reports false if the connection is draining and doesn't take new calls
+func (c *connection) startCall() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.draining {
+		return false
+	}
+
+	c.inflight.Add(1)
+
+	return true
+}
+
+func (c *connection) rejectTextMessage(msg []byte) {
+	var req jsonrpc.Request
+	if err := json.Unmarshal(msg, &req); err != nil || req.IsNotification() {
+		return
+	}
+
+	c.send(req.ErrorResponse(errShuttingDown))
+}
+
+func (c *connection) drain(ctx context.Context) {
+	c.mutex.Lock()
+	c.draining = true
+	c.mutex.Unlock()
+
+	defer c.conn.Close()
+
+	payload, err := json.Marshal(goingAway{Reason: errShuttingDown.Error()})
+	if err != nil {
+		panic(err)
+	}
+
+	if !c.sendWithin(ctx, jsonrpc.Request{Version: "2.0", Method: GoingAwayMethod, Params: payload}) {
+		return
+	}
+
+	idleC := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(idleC)
+	}()
+
+	select {
+	case <-idleC:
+	case <-ctx.Done():
+		c.log.Printf("[%s] Closing connection with calls in progress", c.conn.RemoteAddr())
+		return
+	}
+
+	if !c.sendWithin(ctx, closeMessage{code: websocket.CloseGoingAway, text: errShuttingDown.Error()}) {
+		return
+	}
+
+	// give the client a chance to acknowledge closing
+	select {
+	case <-c.doneC:
+	case <-ctx.Done():
+	}
+}
+
+func (c *connection) sendWithin(ctx context.Context, msg interface{}) bool {
+	select {
+	case c.sendC <- msg:
+		return true
+	case <-c.doneC:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -1,24 +1,30 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"reflect"
+
+	"github.com/dmytro-vovk/tro/internal/jsonrpc"
 )
 
 // rpcHandler structure which describes how handler should look like,
 // it more than enough for any cases
 type rpcHandler struct {
-	fn  reflect.Value // handler function which would be called for the API endpoint
-	arg reflect.Type  // argument of this function, it represents as specific request structure, also can be <nil>
+	fn      reflect.Value // handler function which would be called for the API endpoint
+	arg     reflect.Type  // argument of this function, it represents as specific request structure, also can be <nil>
+	withCtx bool          // the function takes context of the call first
 }
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 /*
 parseHandler brings all functions to the same interface.
 
 Handler function design will be looks like:
 [] - means that this argument is optional
 
-func handlerName([r requestStruct]) ([responseStruct,] error) {
+func handlerName([ctx context.Context,] [r requestStruct]) ([responseStruct,] error) {
 	// handler body...
 }
 
@@ -34,9 +40,15 @@ func parseHandler(fn interface{}) rpcHandler {
 		panic("function expected")
 	}
 
-	// check function arguments
-	if h.NumIn() > 1 {
-		panic("expected at most one handler argument")
+	// check function arguments, context doesn't count
+	withCtx := h.NumIn() > 0 && h.In(0) == contextType
+	args := h.NumIn()
+	if withCtx {
+		args--
+	}
+
+	if args > 1 {
+		panic("expected at most one handler argument besides context")
 	}
 
 	// check function return values
@@ -52,13 +64,14 @@ func parseHandler(fn interface{}) rpcHandler {
 
 	// define request structure if we have it
 	var req reflect.Type
-	if n := h.NumIn(); n == 1 {
-		req = h.In(n - 1)
+	if args == 1 {
+		req = h.In(h.NumIn() - 1)
 	}
 
 	return rpcHandler{
-		fn:  reflect.ValueOf(fn),
-		arg: req,
+		fn:      reflect.ValueOf(fn),
+		arg:     req,
+		withCtx: withCtx,
 	}
 }
 
@@ -68,22 +81,24 @@ call makes function call and returns handler's response to the client
 Firstly it parses and initializes function parameters with which function would be called.
 Then makes function call with it and return handler's response.
 */
-func (h *rpcHandler) call(params json.RawMessage) (json.RawMessage, error) {
-	// call function immediately for get it's return value
-	var ret []reflect.Value
-	if h.arg == nil {
-		ret = h.fn.Call(nil)
-	} else {
+func (h *rpcHandler) call(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	var in []reflect.Value
+	if h.withCtx {
+		in = append(in, reflect.ValueOf(&ctx).Elem()) // of the interface type, ctx may be any implementation
+	}
+
+	if h.arg != nil {
 		value := reflect.New(h.arg).Interface()
 		if err := json.Unmarshal(params, &value); err != nil {
-			return nil, err
+			return nil, jsonrpc.NewError(jsonrpc.CodeInvalidParams, err.Error())
 		}
 
-		ret = h.fn.Call([]reflect.Value{
-			reflect.ValueOf(reflect.ValueOf(value).Elem().Interface()), // dereferencing
-		})
+		in = append(in, reflect.ValueOf(reflect.ValueOf(value).Elem().Interface())) // dereferencing
 	}
 
+	// call function immediately for get it's return value
+	ret := h.fn.Call(in)
+
 	// parse return structure ([*responseStruct,] error)
 	switch n := h.fn.Type().NumOut(); {
 	case n == 1:
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"reflect"
@@ -75,6 +76,15 @@ func TestCall(t *testing.T) {
 				err: testErr,
 			},
 		},
+		{
+			name:    "with context and request",
+			request: testReq,
+			handler: func(ctx context.Context, thr testHandlerRequest) (*struct{}, error) { return &struct{}{}, ctx.Err() },
+			expected: ret{
+				msg: json.RawMessage("{}"),
+				err: nil,
+			},
+		},
 		{
 			name:    "data will be lost if you try to return them together with an error",
 			request: testReq,
@@ -95,7 +105,7 @@ func TestCall(t *testing.T) {
 				t.Fatalf("struct should be normally parsed")
 			}
 
-			msg, err := rpc.call(req)
+			msg, err := rpc.call(context.Background(), req)
 			// log.Printf("req: %q = %[1]x, err: %v", msg, err)
 
 			assert.Equal(t, tc.expected.msg, msg)
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"time"
+
+	"github.com/dmytro-vovk/tro/internal/metrics"
+)
+
+// clientMetrics is safe to use when nil, so metrics are optional
+type clientMetrics struct {
+	connections   *metrics.Gauge
+	received      *metrics.Counter
+	sent          *metrics.Counter
+	dropped       *metrics.Counter
+	calls         *metrics.Counter
+	errors        *metrics.Counter
+	callDurations *metrics.Histogram
+}
+
+// Instrument registers websocket and RPC metrics in the registry
+func (c *Client) Instrument(r *metrics.Registry) *Client {
+	c.metrics = &clientMetrics{
+		connections:   r.Gauge("tro_ws_connections", "Number of active websocket connections."),
+		received:      r.Counter("tro_ws_messages_received_total", "Number of messages received over websocket."),
+		sent:          r.Counter("tro_ws_messages_sent_total", "Number of messages sent over websocket."),
+		dropped:       r.Counter("tro_ws_notifications_dropped_total", "Number of notifications not sent because of full send queue.", "method"),
+		calls:         r.Counter("tro_rpc_calls_total", "Number of RPC calls.", "method"),
+		errors:        r.Counter("tro_rpc_errors_total", "Number of RPC calls finished with error.", "method"),
+		callDurations: r.Histogram("tro_rpc_call_duration_seconds", "RPC call latency.", metrics.DefaultBuckets, "method"),
+	}
+
+	r.GaugeFunc("tro_ws_subscriptions", "Number of connections subscribed to the topic.", "topic", func() map[string]float64 {
+		values := map[string]float64{}
+		for topic, n := range c.Subscriptions() {
+			values[topic] = float64(n)
+		}
+
+		return values
+	})
+
+	return c
+}
+
+func (m *clientMetrics) connectionOpened() {
+	if m != nil {
+		m.connections.Inc()
+	}
+}
+
+func (m *clientMetrics) connectionClosed() {
+	if m != nil {
+		m.connections.Dec()
+	}
+}
+
+func (m *clientMetrics) messageReceived() {
+	if m != nil {
+		m.received.Inc()
+	}
+}
+
+func (m *clientMetrics) messageSent() {
+	if m != nil {
+		m.sent.Inc()
+	}
+}
+
+func (m *clientMetrics) notificationDropped(method string) {
+	if m != nil {
+		m.dropped.Inc(method)
+	}
+}
+
+func (m *clientMetrics) called(method string, d time.Duration, failed bool) {
+	if m == nil {
+		return
+	}
+
+	m.calls.Inc(method)
+	m.callDurations.Observe(d.Seconds(), method)
+
+	if failed {
+		m.errors.Inc(method)
+	}
+}
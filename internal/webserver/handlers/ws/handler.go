@@ -18,14 +18,19 @@ func NewHandler(c *client.Client) *Handler {
 
 // Handler handles the websockets
 func (h *Handler) Handler(w http.ResponseWriter, r *http.Request) {
+	if h.client.Draining() {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
 	conn, err := (&websocket.Upgrader{
 		EnableCompression: true,
 		CheckOrigin:       func(*http.Request) bool { return true },
-	}).Upgrade(w, r, nil)
+	}).Upgrade(w, r, w.Header()) // has headers of middleware, e.g. the request id
 	if err != nil {
 		logrus.Printf("Error upgrading connection to websocket: %s", err)
 		return
 	}
 
-	h.client.Run(conn)
+	h.client.Run(r.Context(), conn)
 }
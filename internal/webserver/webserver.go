@@ -96,7 +96,10 @@ func (w *Webserver) Serve(name string) (err error) {
 	}
 
 	<-started
-	w.server.Addr = ":80"
+	// with TLS plain HTTP is only for ACME challenges, other servers keep their configured address
+	if w.server.TLSConfig != nil {
+		w.server.Addr = ":80"
+	}
 	err = w.server.ListenAndServe()
 	if errors.Is(err, http.ErrServerClosed) {
 		err = nil